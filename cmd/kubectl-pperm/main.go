@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -11,6 +12,9 @@ import (
 	"github.com/berkguzel/pperm/pkg/printer"
 )
 
+// errNotAllowed makes can-i exit with status 1 once it has printed its answer
+var errNotAllowed = errors.New("not allowed")
+
 func main() {
 	opts := options.NewOptions()
 	if err := opts.Parse(); err != nil {
@@ -23,14 +27,10 @@ func main() {
 		return
 	}
 
-	// Validate required fields
-	if opts.PodName == "" {
-		fmt.Fprintln(os.Stderr, "Error: pod name is required")
-		os.Exit(1)
-	}
-
 	if err := run(opts); err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
+		if !errors.Is(err, errNotAllowed) {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
 		os.Exit(1)
 	}
 }
@@ -57,5 +57,17 @@ func run(opts *options.Options) error {
 		return err
 	}
 
+	switch opts.Command {
+	case options.CommandCanI:
+		if !printer.PrintCanI(results, opts) {
+			return errNotAllowed
+		}
+		return nil
+	case options.CommandHistory:
+		return printer.PrintHistory(results, opts)
+	case options.CommandSuggest:
+		return printer.PrintSuggestion(results, opts)
+	}
+
 	return printer.Print(results, opts)
 }
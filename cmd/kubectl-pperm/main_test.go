@@ -14,7 +14,7 @@ func TestRun(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name: "missing pod name",
+			name: "namespace scan without cluster access",
 			opts: &options.Options{
 				PodName: "",
 			},
@@ -1,23 +1,110 @@
 package options
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/mitchellh/go-homedir"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// Workload kinds that can be analyzed by KIND/NAME reference
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+	KindJob         = "Job"
+	KindCronJob     = "CronJob"
+)
+
+// Subcommands selected by the first positional argument
+const (
+	CommandCanI    = "can-i"   // Whether a pod may perform an action on a resource
+	CommandHistory = "history" // How a managed policy of a pod changed over its versions
+	CommandSuggest = "suggest" // A least-privilege policy from the calls CloudTrail recorded
+)
+
+// Orders --sort-by can list pods in
+const (
+	SortByRisk = "risk" // Riskiest pod first
+)
+
+// defaultUnusedDays is how long a permission may go without use before
+// --unused reports it as unused
+const defaultUnusedDays = 90
+
+// workloadKinds maps the resource names and short names accepted on the
+// command line to their workload kind, mirroring kubectl
+var workloadKinds = map[string]string{
+	"deployment":   KindDeployment,
+	"deployments":  KindDeployment,
+	"deploy":       KindDeployment,
+	"statefulset":  KindStatefulSet,
+	"statefulsets": KindStatefulSet,
+	"sts":          KindStatefulSet,
+	"daemonset":    KindDaemonSet,
+	"daemonsets":   KindDaemonSet,
+	"ds":           KindDaemonSet,
+	"job":          KindJob,
+	"jobs":         KindJob,
+	"cronjob":      KindCronJob,
+	"cronjobs":     KindCronJob,
+	"cj":           KindCronJob,
+}
+
 type Options struct {
-	PodName       string
-	Namespace     string
-	ShowPerms     bool
-	InspectPolicy bool
-	RiskOnly      bool
-	KubeConfig    string
-	Help          bool
+	Command            string
+	Actions            []string
+	Resources          []string
+	Simulate           bool
+	Context            map[string][]string // Condition key values of the request, from --context and --context-file
+	PolicyName         string
+	Versions           []string // Policy versions to compare with history, e.g. v1 and v4
+	CloudTrailDir      string   // Directory of CloudTrail log files to suggest a policy from
+	PodName            string
+	WorkloadKind       string
+	WorkloadName       string
+	ServiceAccountName string
+	Namespace          string
+	AllNamespaces      bool
+	LabelSelector      string
+	FieldSelector      string
+	ClusterName        string
+	ShowPerms          bool
+	InspectPolicy      bool
+	RiskOnly           bool
+	ExpandActions      bool
+	Unused             bool
+	UnusedDays         int    // Permissions not used for this many days count as unused
+	SortBy             string // Order of the pods in the report, e.g. SortByRisk
+	RulesFile          string // YAML or JSON file of risk rules, from --rules or the config directory
+	KubeConfig         string
+	Help               bool
+}
+
+// rulesFileNames are the names of the risk rules file looked up in the pperm
+// configuration directory, e.g. ~/.config/pperm on Linux
+var rulesFileNames = []string{"rules.yaml", "rules.yml", "rules.json"}
+
+// defaultRulesFile returns the risk rules file of the pperm configuration
+// directory, or "" when there is none
+func defaultRulesFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	for _, name := range rulesFileNames {
+		path := filepath.Join(dir, "pperm", name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
 }
 
 // getCurrentNamespace gets the current namespace from the kubeconfig
@@ -48,17 +135,86 @@ func getCurrentNamespace(kubeconfigPath string) string {
 	return "default"
 }
 
+// getCurrentEKSCluster gets the EKS cluster name of the current context from
+// the kubeconfig. Contexts created by "aws eks update-kubeconfig" reference
+// the cluster by its ARN, e.g. arn:aws:eks:us-west-2:123456789012:cluster/prod.
+func getCurrentEKSCluster(kubeconfigPath string) string {
+	config, err := clientcmd.LoadFromFile(kubeconfigPath)
+	if err != nil {
+		return ""
+	}
+
+	context, exists := config.Contexts[config.CurrentContext]
+	if !exists || context == nil {
+		return ""
+	}
+
+	if !strings.HasPrefix(context.Cluster, "arn:aws:eks:") {
+		return ""
+	}
+
+	_, name, found := strings.Cut(context.Cluster, ":cluster/")
+	if !found {
+		return ""
+	}
+
+	return name
+}
+
 func printUsage() {
-	fmt.Printf(`Usage: kubectl pperm [flags] POD_NAME
+	fmt.Printf(`Usage: kubectl pperm [flags] [POD_NAME | KIND/NAME]
+       kubectl pperm can-i [flags] POD_NAME | KIND/NAME ACTION[,ACTION...] RESOURCE...
+       kubectl pperm history [flags] POD_NAME | KIND/NAME --policy NAME [VERSION VERSION]
+       kubectl pperm suggest [flags] POD_NAME | KIND/NAME --cloudtrail DIR
 
 Display AWS IAM permissions for pods in Kubernetes clusters.
+When POD_NAME is omitted, every pod in the namespace is analyzed.
+KIND/NAME analyzes a workload's pod template without needing a running
+pod; KIND is one of deploy, sts, ds, job or cronjob. sa/NAME analyzes a
+service account directly.
+can-i answers whether the target may perform each ACTION on each RESOURCE,
+naming the statements that decide it, and exits with status 1 when it may
+not.
+history lists the versions IAM keeps of one of the target's managed
+policies with the permissions each version added and removed, or the
+changes between two given versions.
+suggest builds a policy granting only the calls the target's role made
+according to the CloudTrail logs in DIR, and shows how it differs from the
+role's current policies.
 
 Flags:
   -h, --help              Show help message
-  -i, --inspect-policy    Inspect detailed policy information
-  -r, --risk-only         Show only permissions with high risk or broad scope
+  -i, --inspect-policy    Inspect the policies of a single pod, workload or
+                          service account interactively
+  -r, --risk-only         Show only permissions with a High or Critical risk score
+  --sort-by risk          List the riskiest pods first
+  --rules                 YAML or JSON file of risk rules to add to the built-in
+                          ones (defaults to rules.yaml in the pperm config
+                          directory, e.g. ~/.config/pperm)
   --permissions           Show detailed permissions list
+  --expand-actions        List the concrete actions each wildcard action grants
+                          (implies --permissions)
+  --unused                Mark each permission with when the role last used it,
+                          from IAM last-accessed data (implies --permissions)
+  --unused-days           Days without use after which a permission counts as
+                          unused (default 90)
   -n, --namespace         Namespace of the pod (defaults to current namespace)
+  -A, --all-namespaces    Analyze pods in every namespace of the cluster
+  -l, --selector          Label selector to filter pods (e.g. app=api,tier=backend)
+  --field-selector        Field selector to filter pods (e.g. spec.nodeName=node-1)
+  --simulate              With can-i, also ask the AWS IAM policy simulator and
+                          report where it disagrees with pperm
+  --context KEY=VALUE     Value of a condition key of the request, e.g.
+                          aws:SourceVpc=vpc-123; repeat for more keys or values.
+                          Conditions are then evaluated, treating keys not given
+                          as absent from the request
+  --context-file          JSON file mapping condition keys to a value or a list
+                          of values
+  --policy                With history, name or ARN of the managed policy
+  --cloudtrail            With suggest, directory of CloudTrail log files
+                          (.json or .json.gz), searched recursively
+  --cluster               EKS cluster name used to look up Pod Identity associations
+                          (defaults to the cluster of the current EKS context)
 
 Examples:
   # Show policy overview (default behavior)
@@ -67,15 +223,61 @@ Examples:
   # Show only high-risk permissions
   kubectl pperm my-pod -r
 
+  # Find the riskiest workloads of a namespace
+  kubectl pperm -n my-namespace --sort-by risk
+
+  # Flag permissions with your own risk rules
+  kubectl pperm -n my-namespace --rules ./risk-rules.yaml
+
   # Show detailed permissions list
   kubectl pperm my-pod --permissions
 
+  # List every action behind wildcards such as s3:Get*
+  kubectl pperm my-pod --expand-actions
+
+  # Find permissions the pod has not used in the last 30 days
+  kubectl pperm my-pod --unused --unused-days 30
+
   # Inspect detailed policy information
   kubectl pperm my-pod -i
 
   # Specify a namespace
   kubectl pperm my-pod -n my-namespace
 
+  # Analyze every pod in a namespace
+  kubectl pperm -n my-namespace
+
+  # Analyze every pod in the cluster
+  kubectl pperm -A
+
+  # Analyze pods matching a label selector
+  kubectl pperm -l app=api,tier=backend
+
+  # Analyze a workload, even when it has no running pods
+  kubectl pperm deploy/api
+  kubectl pperm cronjob/nightly-export
+
+  # Analyze a service account before any pod uses it
+  kubectl pperm sa/api
+
+  # Check whether a pod may write a report, and why not
+  kubectl pperm can-i my-pod s3:PutObject 'arn:aws:s3:::reports/2024/*'
+
+  # Check a permission that depends on conditions, from within a VPC
+  kubectl pperm can-i my-pod s3:GetObject arn:aws:s3:::reports/a --context aws:SourceVpc=vpc-123 --context aws:PrincipalTag/team=payments
+
+  # Double-check the answers with the AWS IAM policy simulator
+  kubectl pperm can-i my-pod s3:GetObject,s3:PutObject arn:aws:s3:::reports/a arn:aws:s3:::payroll/b --simulate
+
+  # Find out when a policy started granting a permission
+  kubectl pperm history my-pod --policy reports-writer
+
+  # Compare two versions of a policy
+  kubectl pperm history my-pod --policy reports-writer v1 v4
+
+  # Suggest a least-privilege policy from a week of CloudTrail logs
+  kubectl pperm suggest deploy/api --cloudtrail ./cloudtrail-logs
+
 `)
 }
 
@@ -90,12 +292,16 @@ func NewOptions() *Options {
 		kubeconfig = filepath.Join(home, ".kube", "config")
 	}
 
-	// Get the current namespace from kubeconfig
+	// Get the current namespace and EKS cluster from kubeconfig
 	currentNamespace := getCurrentNamespace(kubeconfig)
+	currentCluster := getCurrentEKSCluster(kubeconfig)
 
 	return &Options{
-		KubeConfig: kubeconfig,
-		Namespace:  currentNamespace,
+		KubeConfig:  kubeconfig,
+		Namespace:   currentNamespace,
+		ClusterName: currentCluster,
+		UnusedDays:  defaultUnusedDays,
+		RulesFile:   defaultRulesFile(),
 	}
 }
 
@@ -121,23 +327,238 @@ func (o *Options) Parse() error {
 			o.RiskOnly = true
 		case "--permissions":
 			o.ShowPerms = true
+		case "--simulate":
+			o.Simulate = true
+		case "--expand-actions":
+			o.ExpandActions = true
+			o.ShowPerms = true
+		case "--context":
+			if i+1 < len(args) {
+				i++
+				if err := o.addContext(args[i]); err != nil {
+					return err
+				}
+			}
+		case "--context-file":
+			if i+1 < len(args) {
+				i++
+				if err := o.loadContextFile(args[i]); err != nil {
+					return err
+				}
+			}
+		case "--unused":
+			o.Unused = true
+			o.ShowPerms = true
+		case "--unused-days":
+			if i+1 < len(args) {
+				i++
+				days, err := strconv.Atoi(args[i])
+				if err != nil || days <= 0 {
+					return fmt.Errorf("invalid --unused-days %q: expected a positive number of days", args[i])
+				}
+				o.UnusedDays = days
+			}
+		case "--sort-by":
+			if i+1 < len(args) {
+				i++
+				if args[i] != SortByRisk {
+					return fmt.Errorf("invalid --sort-by %q: expected %s", args[i], SortByRisk)
+				}
+				o.SortBy = args[i]
+			}
+		case "--rules":
+			if i+1 < len(args) {
+				i++
+				o.RulesFile = args[i]
+			}
+		case "--policy":
+			if i+1 < len(args) {
+				i++
+				o.PolicyName = args[i]
+			}
+		case "--cloudtrail":
+			if i+1 < len(args) {
+				i++
+				o.CloudTrailDir = args[i]
+			}
 		case "-n", "--namespace":
 			if i+1 < len(args) {
 				i++
 				o.Namespace = args[i]
 			}
+		case "-A", "--all-namespaces":
+			o.AllNamespaces = true
+		case "-l", "--selector":
+			if i+1 < len(args) {
+				i++
+				o.LabelSelector = args[i]
+			}
+		case "--field-selector":
+			if i+1 < len(args) {
+				i++
+				o.FieldSelector = args[i]
+			}
+		case "--cluster":
+			if i+1 < len(args) {
+				i++
+				o.ClusterName = args[i]
+			}
 		case "--kubeconfig":
 			if i+1 < len(args) {
 				i++
 				o.KubeConfig = args[i]
 			}
 		default:
-			// If it doesn't start with '-', treat it as pod name
+			// If it doesn't start with '-', treat it as a subcommand, pod
+			// name or KIND/NAME, or an argument of the subcommand
 			if !strings.HasPrefix(arg, "-") {
-				o.PodName = arg
+				if err := o.setPositional(arg); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if o.InspectPolicy && !o.HasTarget() {
+		return fmt.Errorf("--inspect-policy inspects a single pod, workload or service account; name one")
+	}
+	if o.Command == CommandCanI && (!o.HasTarget() || len(o.Actions) == 0 || len(o.Resources) == 0) {
+		return fmt.Errorf("usage: kubectl pperm can-i POD_NAME | KIND/NAME ACTION[,ACTION...] RESOURCE...")
+	}
+	if o.Simulate && o.Command != CommandCanI {
+		return fmt.Errorf("--simulate can only be used with can-i")
+	}
+	if o.Command == CommandHistory && (!o.HasTarget() || o.PolicyName == "") {
+		return fmt.Errorf("usage: kubectl pperm history POD_NAME | KIND/NAME --policy NAME [VERSION VERSION]")
+	}
+	if o.Command == CommandHistory && len(o.Versions) != 0 && len(o.Versions) != 2 {
+		return fmt.Errorf("history compares exactly two versions, got %d", len(o.Versions))
+	}
+	if o.PolicyName != "" && o.Command != CommandHistory {
+		return fmt.Errorf("--policy can only be used with history")
+	}
+	if o.Command == CommandSuggest && (!o.HasTarget() || o.CloudTrailDir == "") {
+		return fmt.Errorf("usage: kubectl pperm suggest POD_NAME | KIND/NAME --cloudtrail DIR")
+	}
+	if o.CloudTrailDir != "" && o.Command != CommandSuggest {
+		return fmt.Errorf("--cloudtrail can only be used with suggest")
+	}
+
+	return nil
+}
+
+// addContext records a KEY=VALUE condition key value. Giving a key again adds
+// a value, for multivalued keys such as aws:TagKeys.
+func (o *Options) addContext(arg string) error {
+	key, value, found := strings.Cut(arg, "=")
+	if !found || key == "" {
+		return fmt.Errorf("invalid --context %q: expected KEY=VALUE", arg)
+	}
+	if o.Context == nil {
+		o.Context = make(map[string][]string)
+	}
+	o.Context[key] = append(o.Context[key], value)
+	return nil
+}
+
+// loadContextFile records the condition key values of a JSON file such as
+// {"aws:SourceVpc": "vpc-123", "aws:TagKeys": ["team", "env"]}
+func (o *Options) loadContextFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read context file: %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("failed to parse context file %s: %v", path, err)
+	}
+
+	for key, value := range raw {
+		values, ok := value.([]interface{})
+		if !ok {
+			values = []interface{}{value}
+		}
+		for _, v := range values {
+			switch v.(type) {
+			case string, bool, float64:
+				if err := o.addContext(fmt.Sprintf("%s=%v", key, v)); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("invalid value of %s in context file %s: expected a string, number, boolean or a list of them", key, path)
 			}
 		}
 	}
+	return nil
+}
 
+// setPositional records a positional argument. A leading can-i, history or
+// suggest selects the subcommand. can-i takes a comma-separated list of actions after
+// the target followed by any number of resources. Resources are not split on
+// commas, since ARNs may contain them. history takes the policy versions to
+// compare after the target, with or without their v prefix.
+func (o *Options) setPositional(arg string) error {
+	switch {
+	case (arg == CommandCanI || arg == CommandHistory || arg == CommandSuggest) && o.Command == "" && !o.HasTarget():
+		o.Command = arg
+	case o.Command == CommandHistory && o.HasTarget():
+		if !strings.HasPrefix(arg, "v") {
+			arg = "v" + arg
+		}
+		o.Versions = append(o.Versions, arg)
+	case o.Command == CommandCanI && o.HasTarget() && len(o.Actions) == 0:
+		for _, action := range strings.Split(arg, ",") {
+			if action = strings.TrimSpace(action); action != "" {
+				o.Actions = append(o.Actions, action)
+			}
+		}
+	case o.Command == CommandCanI && o.HasTarget():
+		o.Resources = append(o.Resources, arg)
+	default:
+		return o.setTarget(arg)
+	}
 	return nil
 }
+
+// setTarget records the positional argument, which is either a bare pod name
+// or a KIND/NAME reference such as deploy/api. Only one target can be given.
+func (o *Options) setTarget(arg string) error {
+	if o.HasTarget() {
+		return fmt.Errorf("unexpected argument %q: only one pod, workload or service account can be analyzed at a time", arg)
+	}
+
+	kind, name, found := strings.Cut(arg, "/")
+	if !found {
+		o.PodName = arg
+		return nil
+	}
+
+	if name == "" {
+		return fmt.Errorf("invalid resource reference %q: missing name", arg)
+	}
+
+	switch kind = strings.ToLower(kind); kind {
+	case "pod", "pods", "po":
+		o.PodName = name
+		return nil
+	case "serviceaccount", "serviceaccounts", "sa":
+		o.ServiceAccountName = name
+		return nil
+	}
+
+	workloadKind, ok := workloadKinds[kind]
+	if !ok {
+		return fmt.Errorf("unsupported resource type %q", kind)
+	}
+	o.WorkloadKind = workloadKind
+	o.WorkloadName = name
+
+	return nil
+}
+
+// HasTarget reports whether a single named pod, workload or service account
+// was requested, as opposed to a scan over many pods
+func (o *Options) HasTarget() bool {
+	return o.PodName != "" || o.WorkloadName != "" || o.ServiceAccountName != ""
+}
@@ -2,6 +2,7 @@ package options
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,6 +25,17 @@ func TestNewOptions(t *testing.T) {
 		opts := NewOptions()
 		assert.Contains(t, opts.KubeConfig, ".kube/config")
 	})
+
+	t.Run("finds risk rules in the config directory", func(t *testing.T) {
+		configDir := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", configDir)
+		assert.Empty(t, NewOptions().RulesFile)
+
+		rulesFile := filepath.Join(configDir, "pperm", "rules.yml")
+		assert.NoError(t, os.MkdirAll(filepath.Dir(rulesFile), 0o700))
+		assert.NoError(t, os.WriteFile(rulesFile, []byte("rules: []"), 0o600))
+		assert.Equal(t, rulesFile, NewOptions().RulesFile)
+	})
 }
 
 func TestOptions_Parse(t *testing.T) {
@@ -43,6 +55,16 @@ func TestOptions_Parse(t *testing.T) {
 	os.Setenv("KUBECONFIG", tmpKubeconfig.Name())
 	defer os.Setenv("KUBECONFIG", originalKubeconfig)
 
+	contextFile, err := os.CreateTemp("", "context*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(contextFile.Name())
+	if _, err := contextFile.WriteString(`{"aws:SourceVpc": "vpc-123", "aws:TagKeys": ["team", "env"], "aws:SecureTransport": true}`); err != nil {
+		t.Fatal(err)
+	}
+	contextFile.Close()
+
 	tests := []struct {
 		name     string
 		args     []string
@@ -76,6 +98,293 @@ func TestOptions_Parse(t *testing.T) {
 				ShowPerms:     true,
 			},
 		},
+		{
+			name: "all namespaces",
+			args: []string{"pperm", "-A", "--permissions"},
+			expected: Options{
+				Namespace:     "default",
+				AllNamespaces: true,
+				ShowPerms:     true,
+			},
+		},
+		{
+			name: "selectors",
+			args: []string{"pperm", "-l", "app=api,tier=backend", "--field-selector", "spec.nodeName=node-1"},
+			expected: Options{
+				Namespace:     "default",
+				LabelSelector: "app=api,tier=backend",
+				FieldSelector: "spec.nodeName=node-1",
+			},
+		},
+		{
+			name: "workload reference",
+			args: []string{"pperm", "deploy/api"},
+			expected: Options{
+				Namespace:    "default",
+				WorkloadKind: KindDeployment,
+				WorkloadName: "api",
+			},
+		},
+		{
+			name: "cronjob reference",
+			args: []string{"pperm", "cronjob/nightly-export", "-n", "reports"},
+			expected: Options{
+				Namespace:    "reports",
+				WorkloadKind: KindCronJob,
+				WorkloadName: "nightly-export",
+			},
+		},
+		{
+			name: "pod reference",
+			args: []string{"pperm", "pod/my-pod"},
+			expected: Options{
+				Namespace: "default",
+				PodName:   "my-pod",
+			},
+		},
+		{
+			name: "service account reference",
+			args: []string{"pperm", "sa/api-sa", "-n", "payments"},
+			expected: Options{
+				Namespace:          "payments",
+				ServiceAccountName: "api-sa",
+			},
+		},
+		{
+			name:    "unsupported resource type",
+			args:    []string{"pperm", "replicaset/api"},
+			wantErr: true,
+		},
+		{
+			name:    "missing workload name",
+			args:    []string{"pperm", "deploy/"},
+			wantErr: true,
+		},
+		{
+			name: "cluster name",
+			args: []string{"pperm", "my-pod", "--cluster", "prod"},
+			expected: Options{
+				Namespace:   "default",
+				PodName:     "my-pod",
+				ClusterName: "prod",
+			},
+		},
+		{
+			name: "can-i",
+			args: []string{"pperm", "can-i", "my-pod", "s3:PutObject", "arn:aws:s3:::reports/2024/*", "-n", "test-ns"},
+			expected: Options{
+				Command:   CommandCanI,
+				Actions:   []string{"s3:PutObject"},
+				Resources: []string{"arn:aws:s3:::reports/2024/*"},
+				PodName:   "my-pod",
+				Namespace: "test-ns",
+			},
+		},
+		{
+			name: "can-i with workload reference",
+			args: []string{"pperm", "can-i", "deploy/api", "sqs:SendMessage", "*"},
+			expected: Options{
+				Command:      CommandCanI,
+				Actions:      []string{"sqs:SendMessage"},
+				Resources:    []string{"*"},
+				WorkloadKind: KindDeployment,
+				WorkloadName: "api",
+				Namespace:    "default",
+			},
+		},
+		{
+			name:    "can-i missing resource",
+			args:    []string{"pperm", "can-i", "my-pod", "s3:PutObject"},
+			wantErr: true,
+		},
+		{
+			name: "can-i with several actions and resources",
+			args: []string{"pperm", "can-i", "my-pod", "s3:GetObject,s3:PutObject", "arn:aws:s3:::a", "arn:aws:s3:::b,c", "--simulate"},
+			expected: Options{
+				Command:   CommandCanI,
+				Actions:   []string{"s3:GetObject", "s3:PutObject"},
+				Resources: []string{"arn:aws:s3:::a", "arn:aws:s3:::b,c"},
+				Simulate:  true,
+				PodName:   "my-pod",
+				Namespace: "default",
+			},
+		},
+		{
+			name:    "simulate without can-i",
+			args:    []string{"pperm", "my-pod", "--simulate"},
+			wantErr: true,
+		},
+		{
+			name: "context",
+			args: []string{"pperm", "can-i", "my-pod", "s3:GetObject", "*", "--context", "aws:SourceVpc=vpc-123",
+				"--context", "aws:TagKeys=team", "--context", "aws:TagKeys=env", "--context", "s3:prefix="},
+			expected: Options{
+				Command:   CommandCanI,
+				Actions:   []string{"s3:GetObject"},
+				Resources: []string{"*"},
+				PodName:   "my-pod",
+				Namespace: "default",
+				Context: map[string][]string{
+					"aws:SourceVpc": {"vpc-123"},
+					"aws:TagKeys":   {"team", "env"},
+					"s3:prefix":     {""},
+				},
+			},
+		},
+		{
+			name:    "context without value",
+			args:    []string{"pperm", "my-pod", "--context", "aws:SourceVpc"},
+			wantErr: true,
+		},
+		{
+			name: "context file",
+			args: []string{"pperm", "my-pod", "--context-file", contextFile.Name()},
+			expected: Options{
+				PodName:   "my-pod",
+				Namespace: "default",
+				Context: map[string][]string{
+					"aws:SourceVpc":       {"vpc-123"},
+					"aws:TagKeys":         {"team", "env"},
+					"aws:SecureTransport": {"true"},
+				},
+			},
+		},
+		{
+			name:    "missing context file",
+			args:    []string{"pperm", "my-pod", "--context-file", "/nonexistent/context.json"},
+			wantErr: true,
+		},
+		{
+			name: "history",
+			args: []string{"pperm", "history", "deploy/api", "--policy", "reports-writer"},
+			expected: Options{
+				Command:      CommandHistory,
+				PolicyName:   "reports-writer",
+				WorkloadKind: KindDeployment,
+				WorkloadName: "api",
+				Namespace:    "default",
+			},
+		},
+		{
+			name: "history comparing two versions",
+			args: []string{"pperm", "history", "my-pod", "v1", "4", "--policy", "reports-writer"},
+			expected: Options{
+				Command:    CommandHistory,
+				PolicyName: "reports-writer",
+				Versions:   []string{"v1", "v4"},
+				PodName:    "my-pod",
+				Namespace:  "default",
+			},
+		},
+		{
+			name:    "history without policy",
+			args:    []string{"pperm", "history", "my-pod"},
+			wantErr: true,
+		},
+		{
+			name:    "history with a single version",
+			args:    []string{"pperm", "history", "my-pod", "v2", "--policy", "reports-writer"},
+			wantErr: true,
+		},
+		{
+			name:    "two pods",
+			args:    []string{"pperm", "api", "worker"},
+			wantErr: true,
+		},
+		{
+			name:    "pod and workload",
+			args:    []string{"pperm", "api", "deploy/worker"},
+			wantErr: true,
+		},
+		{
+			name:    "suggest for two targets",
+			args:    []string{"pperm", "suggest", "deploy/api", "sa/worker", "--cloudtrail", "./logs"},
+			wantErr: true,
+		},
+		{
+			name:    "inspect without a target",
+			args:    []string{"pperm", "-n", "payments", "-i"},
+			wantErr: true,
+		},
+		{
+			name:    "inspect across all namespaces",
+			args:    []string{"pperm", "-A", "--inspect-policy"},
+			wantErr: true,
+		},
+		{
+			name:    "policy without history",
+			args:    []string{"pperm", "my-pod", "--policy", "reports-writer"},
+			wantErr: true,
+		},
+		{
+			name: "suggest",
+			args: []string{"pperm", "suggest", "deploy/api", "--cloudtrail", "./logs"},
+			expected: Options{
+				Command:       CommandSuggest,
+				CloudTrailDir: "./logs",
+				WorkloadKind:  KindDeployment,
+				WorkloadName:  "api",
+				Namespace:     "default",
+			},
+		},
+		{
+			name:    "suggest without logs",
+			args:    []string{"pperm", "suggest", "my-pod"},
+			wantErr: true,
+		},
+		{
+			name:    "cloudtrail without suggest",
+			args:    []string{"pperm", "my-pod", "--cloudtrail", "./logs"},
+			wantErr: true,
+		},
+		{
+			name: "unused",
+			args: []string{"pperm", "my-pod", "--unused", "--unused-days", "30"},
+			expected: Options{
+				Namespace:  "default",
+				PodName:    "my-pod",
+				ShowPerms:  true,
+				Unused:     true,
+				UnusedDays: 30,
+			},
+		},
+		{
+			name:    "invalid unused days",
+			args:    []string{"pperm", "my-pod", "--unused", "--unused-days", "0"},
+			wantErr: true,
+		},
+		{
+			name: "sort by risk",
+			args: []string{"pperm", "-n", "payments", "--sort-by", "risk"},
+			expected: Options{
+				Namespace: "payments",
+				SortBy:    SortByRisk,
+			},
+		},
+		{
+			name:    "invalid sort order",
+			args:    []string{"pperm", "--sort-by", "name"},
+			wantErr: true,
+		},
+		{
+			name: "rules file",
+			args: []string{"pperm", "my-pod", "--rules", "./risk-rules.yaml"},
+			expected: Options{
+				Namespace: "default",
+				PodName:   "my-pod",
+				RulesFile: "./risk-rules.yaml",
+			},
+		},
+		{
+			name: "expand actions",
+			args: []string{"pperm", "my-pod", "--expand-actions"},
+			expected: Options{
+				Namespace:     "default",
+				PodName:       "my-pod",
+				ShowPerms:     true,
+				ExpandActions: true,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -96,6 +405,91 @@ func TestOptions_Parse(t *testing.T) {
 			assert.Equal(t, tt.expected.InspectPolicy, opts.InspectPolicy)
 			assert.Equal(t, tt.expected.RiskOnly, opts.RiskOnly)
 			assert.Equal(t, tt.expected.ShowPerms, opts.ShowPerms)
+			assert.Equal(t, tt.expected.AllNamespaces, opts.AllNamespaces)
+			assert.Equal(t, tt.expected.WorkloadKind, opts.WorkloadKind)
+			assert.Equal(t, tt.expected.WorkloadName, opts.WorkloadName)
+			assert.Equal(t, tt.expected.ServiceAccountName, opts.ServiceAccountName)
+			assert.Equal(t, tt.expected.ClusterName, opts.ClusterName)
+			assert.Equal(t, tt.expected.LabelSelector, opts.LabelSelector)
+			assert.Equal(t, tt.expected.FieldSelector, opts.FieldSelector)
+			assert.Equal(t, tt.expected.ExpandActions, opts.ExpandActions)
+			assert.Equal(t, tt.expected.Command, opts.Command)
+			assert.Equal(t, tt.expected.Actions, opts.Actions)
+			assert.Equal(t, tt.expected.Resources, opts.Resources)
+			assert.Equal(t, tt.expected.Simulate, opts.Simulate)
+			assert.Equal(t, tt.expected.Context, opts.Context)
+			assert.Equal(t, tt.expected.PolicyName, opts.PolicyName)
+			assert.Equal(t, tt.expected.Versions, opts.Versions)
+			assert.Equal(t, tt.expected.Unused, opts.Unused)
+			assert.Equal(t, tt.expected.SortBy, opts.SortBy)
+			if tt.expected.RulesFile != "" {
+				assert.Equal(t, tt.expected.RulesFile, opts.RulesFile)
+			}
+			if tt.expected.UnusedDays != 0 {
+				assert.Equal(t, tt.expected.UnusedDays, opts.UnusedDays)
+			}
+		})
+	}
+}
+
+func TestGetCurrentEKSCluster(t *testing.T) {
+	tests := []struct {
+		name       string
+		kubeconfig string
+		expected   string
+	}{
+		{
+			name: "EKS cluster ARN",
+			kubeconfig: `apiVersion: v1
+kind: Config
+current-context: prod
+contexts:
+- name: prod
+  context:
+    cluster: arn:aws:eks:us-west-2:123456789012:cluster/prod-cluster
+clusters:
+- name: arn:aws:eks:us-west-2:123456789012:cluster/prod-cluster
+  cluster:
+    server: https://example.com
+`,
+			expected: "prod-cluster",
+		},
+		{
+			name: "non-EKS cluster",
+			kubeconfig: `apiVersion: v1
+kind: Config
+current-context: kind
+contexts:
+- name: kind
+  context:
+    cluster: kind-kind
+clusters:
+- name: kind-kind
+  cluster:
+    server: https://127.0.0.1:6443
+`,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.CreateTemp("", "kubeconfig")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+
+			if _, err := f.WriteString(tt.kubeconfig); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			assert.Equal(t, tt.expected, getCurrentEKSCluster(f.Name()))
 		})
 	}
+
+	t.Run("missing kubeconfig", func(t *testing.T) {
+		assert.Equal(t, "", getCurrentEKSCluster("/nonexistent/kubeconfig"))
+	})
 }
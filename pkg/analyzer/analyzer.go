@@ -2,25 +2,50 @@ package analyzer
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/berkguzel/pperm/internal/options"
 	"github.com/berkguzel/pperm/pkg/aws"
+	"github.com/berkguzel/pperm/pkg/escalation"
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/risk"
 	"github.com/berkguzel/pperm/pkg/types"
 	corev1 "k8s.io/api/core/v1"
 )
 
 type K8sClient interface {
 	GetPod(ctx context.Context, name, namespace string) (Pod, error)
+	ListPods(ctx context.Context, namespace string, selector PodSelector) ([]Pod, error)
+	ListNamespaces(ctx context.Context) ([]string, error)
+	GetWorkloadPodTemplate(ctx context.Context, kind, name, namespace string) (Pod, error)
 	GetServiceAccountIAMRole(ctx context.Context, namespace, saName string) (string, error)
+	GetPodAnnotationIAMRole(ctx context.Context, namespace string, annotations map[string]string) (string, error)
+	GetNodeInstanceID(ctx context.Context, nodeName string) (string, error)
 }
 
 type AWSClient interface {
 	GetRolePolicies(ctx context.Context, roleName string) ([]types.Policy, error)
+	GetRolePermissionsBoundary(ctx context.Context, roleName string) (*types.Policy, error)
 	GetPolicyPermissions(ctx context.Context, policyArn string) ([]types.PermissionDisplay, error)
+	GetPodIdentityRole(ctx context.Context, clusterName, namespace, saName string) (string, error)
+	GetInstanceProfileRole(ctx context.Context, instanceID string) (string, error)
+	GetRoleARN(ctx context.Context, role string) (string, error)
+	SimulatePrincipalPolicy(ctx context.Context, roleArn string, actions, resources []string, reqCtx map[string][]string) ([]types.SimulationResult, error)
+	GetPolicyVersions(ctx context.Context, policyArn string) ([]types.PolicyVersion, error)
+	GetServiceLastAccessed(ctx context.Context, roleArn string) ([]types.ServiceUsage, error)
 }
 
+// Deadlines of the steps of an analysis. A scan gets one per namespace, and
+// the AWS calls following it one per role, so that scanning many namespaces
+// or reporting on many roles does not run out of time as a whole.
+const (
+	scanTimeout = 30 * time.Second
+	roleTimeout = 30 * time.Second
+)
+
 type Analyzer struct {
 	k8sClient K8sClient
 	awsClient AWSClient
@@ -34,17 +59,125 @@ func New(k8sClient K8sClient, awsClient AWSClient) *Analyzer {
 }
 
 type Pod struct {
-	Spec PodSpec
+	Name        string
+	Namespace   string
+	Annotations map[string]string
+	Spec        PodSpec
 }
 
 type PodSpec struct {
 	ServiceAccountName string
+	NodeName           string
+}
+
+// PodSelector narrows a pod listing down to the pods matching the label and
+// field selectors, using the same syntax as kubectl. The zero value matches
+// every pod.
+type PodSelector struct {
+	LabelSelector string
+	FieldSelector string
+}
+
+func (a *Analyzer) analyzeAllNamespaces(ctx context.Context, selector PodSelector, opts *options.Options) ([]types.PodPermissions, error) {
+	listCtx, cancel := context.WithTimeout(ctx, scanTimeout)
+	namespaces, err := a.k8sClient.ListNamespaces(listCtx)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %v", err)
+	}
+
+	// Roles are frequently shared across namespaces, so the policy cache
+	// spans the whole cluster scan
+	rolePolicies := make(map[string]roleAccess)
+
+	results := []types.PodPermissions{}
+	for _, namespace := range namespaces {
+		nsCtx, cancel := context.WithTimeout(ctx, scanTimeout)
+		nsResults, err := a.scanNamespace(nsCtx, namespace, selector, rolePolicies, opts)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, nsResults...)
+	}
+
+	return results, nil
+}
+
+func (a *Analyzer) analyzeNamespace(ctx context.Context, namespace string, selector PodSelector, opts *options.Options) ([]types.PodPermissions, error) {
+	return a.scanNamespace(ctx, namespace, selector, make(map[string]roleAccess), opts)
 }
 
-func (a *Analyzer) analyzeNamespace(_ context.Context, _ string) ([]types.PodPermissions, error) {
-	// TODO: Implement namespace-wide analysis
-	// For now, return empty slice
-	return []types.PodPermissions{}, nil
+// scanNamespace analyzes every pod in the namespace matching the selector,
+// reusing and filling rolePolicies so each IAM role is only fetched once
+func (a *Analyzer) scanNamespace(ctx context.Context, namespace string, selector PodSelector, rolePolicies map[string]roleAccess, opts *options.Options) ([]types.PodPermissions, error) {
+	pods, err := a.k8sClient.ListPods(ctx, namespace, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods in namespace %s: %v", namespace, err)
+	}
+
+	// Many pods share a service account and role annotations, and many
+	// more a node, so resolve each of them only once
+	podRoles := make(map[string]roleResolution)
+	nodeRoles := make(map[string]roleResolution)
+	accessErrs := make(map[string]error)
+
+	results := make([]types.PodPermissions, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Spec.ServiceAccountName == "" {
+			pod.Spec.ServiceAccountName = "default"
+		}
+		saName := pod.Spec.ServiceAccountName
+
+		key := saName + "/" + pod.Annotations[podRoleAnnotation]
+		resolved, ok := podRoles[key]
+		if !ok {
+			resolved.binding, resolved.err = a.resolvePodRole(ctx, namespace, pod, opts)
+			podRoles[key] = resolved
+		}
+		if usesNodeRole(pod, resolved.err) {
+			resolved, ok = nodeRoles[pod.Spec.NodeName]
+			if !ok {
+				resolved.binding, resolved.err = a.resolveNodeRole(ctx, pod.Spec.NodeName)
+				nodeRoles[pod.Spec.NodeName] = resolved
+			}
+		}
+		binding := resolved.binding
+
+		podPerms := types.PodPermissions{
+			PodName:          pod.Name,
+			Namespace:        namespace,
+			ServiceAccount:   saName,
+			IAMRole:          binding.role,
+			CredentialSource: binding.source,
+		}
+		// Pods without an IAM role are still reported so the scan covers
+		// every pod in the namespace
+		if resolved.err != nil && !errors.Is(resolved.err, errNoIAMRole) {
+			podPerms.Error = fmt.Sprintf("failed to resolve IAM role: %v", resolved.err)
+		}
+
+		if binding.role != "" {
+			access, ok := rolePolicies[binding.role]
+			if !ok && accessErrs[binding.role] == nil {
+				access, err = a.getRoleAccess(ctx, binding.role)
+				if err != nil {
+					accessErrs[binding.role] = err
+				} else {
+					rolePolicies[binding.role] = access
+				}
+			}
+			if err := accessErrs[binding.role]; err != nil {
+				podPerms.Error = err.Error()
+			} else {
+				access.apply(&podPerms, evaluator.NewContext(opts.Context))
+			}
+		}
+
+		results = append(results, podPerms)
+	}
+
+	return results, nil
 }
 
 func (a *Analyzer) analyzePod(ctx context.Context, podName, namespace string, opts *options.Options) ([]types.PodPermissions, error) {
@@ -53,40 +186,179 @@ func (a *Analyzer) analyzePod(ctx context.Context, podName, namespace string, op
 		return nil, fmt.Errorf("failed to get pod %s: %v", podName, err)
 	}
 
+	podPerms, err := a.analyzeCredentials(ctx, namespace, pod, opts)
+	if err != nil {
+		return nil, err
+	}
+	podPerms.PodName = podName
+
+	return []types.PodPermissions{podPerms}, nil
+}
+
+// analyzeWorkload resolves the role from a workload's pod template, so
+// workloads without running pods can still be analyzed
+func (a *Analyzer) analyzeWorkload(ctx context.Context, kind, name, namespace string, opts *options.Options) ([]types.PodPermissions, error) {
+	template, err := a.k8sClient.GetWorkloadPodTemplate(ctx, kind, name, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s %s: %v", strings.ToLower(kind), name, err)
+	}
+
+	podPerms, err := a.analyzeCredentials(ctx, namespace, template, opts)
+	if err != nil {
+		return nil, err
+	}
+	podPerms.Workload = kind + "/" + name
+
+	return []types.PodPermissions{podPerms}, nil
+}
+
+// analyzeCredentials resolves the IAM role a pod runs with and the policies
+// attached to it
+func (a *Analyzer) analyzeCredentials(ctx context.Context, namespace string, pod Pod, opts *options.Options) (types.PodPermissions, error) {
+	if pod.Spec.ServiceAccountName == "" {
+		pod.Spec.ServiceAccountName = "default"
+	}
 	saName := pod.Spec.ServiceAccountName
-	if saName == "" {
-		saName = "default"
+
+	binding, err := a.resolveRole(ctx, namespace, pod, opts)
+	if err != nil {
+		return types.PodPermissions{}, fmt.Errorf("no IAM role found for service account %s: %v", saName, err)
 	}
 
-	iamRole, err := a.k8sClient.GetServiceAccountIAMRole(ctx, namespace, saName)
+	access, err := a.getRoleAccess(ctx, binding.role)
 	if err != nil {
-		return nil, fmt.Errorf("no IAM role found for service account %s: %v", saName, err)
+		return types.PodPermissions{}, err
 	}
 
-	policies, err := a.awsClient.GetRolePolicies(ctx, iamRole)
+	podPerms := types.PodPermissions{
+		Namespace:        namespace,
+		ServiceAccount:   saName,
+		IAMRole:          binding.role,
+		CredentialSource: binding.source,
+	}
+	access.apply(&podPerms, evaluator.NewContext(opts.Context))
+
+	return podPerms, nil
+}
+
+// roleAccess is what IAM grants a role: its policies and the permissions
+// boundary capping them
+type roleAccess struct {
+	policies []types.Policy
+	boundary *types.Policy
+}
+
+func (a *Analyzer) getRoleAccess(ctx context.Context, role string) (roleAccess, error) {
+	policies, err := a.awsClient.GetRolePolicies(ctx, role)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get policies for role %s: %v", iamRole, err)
+		return roleAccess{}, fmt.Errorf("failed to get policies for role %s: %v", role, err)
 	}
 
-	return []types.PodPermissions{{
-		PodName:        podName,
-		Namespace:      namespace,
-		ServiceAccount: saName,
-		IAMRole:        iamRole,
-		Policies:       policies,
-	}}, nil
+	boundary, err := a.awsClient.GetRolePermissionsBoundary(ctx, role)
+	if err != nil {
+		return roleAccess{}, fmt.Errorf("failed to get permissions boundary for role %s: %v", role, err)
+	}
+
+	return roleAccess{policies: policies, boundary: boundary}, nil
+}
+
+// apply fills in the policies of a pod, along with their boundary-constrained
+// form when the role has a permissions boundary, and the privilege-escalation
+// paths of what is effectively granted. Allows overridden by an explicit Deny
+// in any of the policies are marked as denied. Conditions are resolved
+// against the request context first, when one was given.
+func (r roleAccess) apply(podPerms *types.PodPermissions, reqCtx evaluator.Context) {
+	podPerms.Policies = evaluator.Annotate(evaluator.Resolve(r.policies, reqCtx))
+	effective := podPerms.Policies
+	if r.boundary != nil {
+		boundary := evaluator.Resolve([]types.Policy{*r.boundary}, reqCtx)[0]
+		podPerms.PermissionsBoundary = &boundary
+		podPerms.BoundedPolicies = evaluator.Annotate(evaluator.Resolve(applyBoundary(r.policies, boundary), reqCtx))
+		effective = podPerms.BoundedPolicies
+	}
+	podPerms.EscalationPaths = escalation.Detect(effective, escalation.Rules)
 }
 
 func (a *Analyzer) Analyze(opts *options.Options) ([]types.PodPermissions, error) {
-	// Create a context with a 30-second timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx := context.Background()
+
+	rules, err := risk.LoadRules(opts.RulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := a.analyze(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	scoreRisk(results, rules)
+
+	if opts.Simulate {
+		if err := a.simulate(ctx, results, opts.Actions, opts.Resources, opts.Context); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Unused {
+		if err := a.lastAccessed(ctx, results); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Command == options.CommandHistory {
+		if err := a.history(ctx, results, opts.PolicyName); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Command == options.CommandSuggest {
+		if err := a.recordedCalls(results, opts.CloudTrailDir); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// analyze resolves the permissions of the pods, workload or service account
+// the options select
+func (a *Analyzer) analyze(ctx context.Context, opts *options.Options) ([]types.PodPermissions, error) {
+	selector := PodSelector{
+		LabelSelector: opts.LabelSelector,
+		FieldSelector: opts.FieldSelector,
+	}
+	if opts.HasTarget() && selector != (PodSelector{}) {
+		return nil, fmt.Errorf("a named target cannot be combined with a label or field selector")
+	}
+
+	if opts.AllNamespaces {
+		if opts.HasTarget() {
+			return nil, fmt.Errorf("a named target cannot be combined with --all-namespaces")
+		}
+		return a.analyzeAllNamespaces(ctx, selector, opts)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, scanTimeout)
 	defer cancel()
 
+	if opts.ServiceAccountName != "" {
+		sa := Pod{Spec: PodSpec{ServiceAccountName: opts.ServiceAccountName}}
+		podPerms, err := a.analyzeCredentials(ctx, opts.Namespace, sa, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []types.PodPermissions{podPerms}, nil
+	}
+
+	if opts.WorkloadName != "" {
+		return a.analyzeWorkload(ctx, opts.WorkloadKind, opts.WorkloadName, opts.Namespace, opts)
+	}
+
 	if opts.PodName != "" {
 		return a.analyzePod(ctx, opts.PodName, opts.Namespace, opts)
 	}
 
-	return a.analyzeNamespace(ctx, opts.Namespace)
+	return a.analyzeNamespace(ctx, opts.Namespace, selector, opts)
 }
 
 func AnalyzePodPermissions(pod *corev1.Pod, awsClient *aws.Client) (types.PodPermissions, error) {
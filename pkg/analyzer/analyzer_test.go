@@ -2,6 +2,7 @@ package analyzer
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	"github.com/berkguzel/pperm/internal/options"
@@ -20,6 +21,31 @@ func (m *MockK8sClient) GetPod(ctx context.Context, name, namespace string) (Pod
 	return args.Get(0).(Pod), args.Error(1)
 }
 
+func (m *MockK8sClient) ListPods(ctx context.Context, namespace string, selector PodSelector) ([]Pod, error) {
+	args := m.Called(ctx, namespace, selector)
+	return args.Get(0).([]Pod), args.Error(1)
+}
+
+func (m *MockK8sClient) ListNamespaces(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockK8sClient) GetWorkloadPodTemplate(ctx context.Context, kind, name, namespace string) (Pod, error) {
+	args := m.Called(ctx, kind, name, namespace)
+	return args.Get(0).(Pod), args.Error(1)
+}
+
+func (m *MockK8sClient) GetPodAnnotationIAMRole(ctx context.Context, namespace string, annotations map[string]string) (string, error) {
+	args := m.Called(ctx, namespace, annotations)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockK8sClient) GetNodeInstanceID(ctx context.Context, nodeName string) (string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockK8sClient) GetServiceAccountIAMRole(ctx context.Context, namespace, saName string) (string, error) {
 	args := m.Called(ctx, namespace, saName)
 	return args.String(0), args.Error(1)
@@ -35,11 +61,46 @@ func (m *MockAWSClient) GetRolePolicies(ctx context.Context, roleName string) ([
 	return args.Get(0).([]types.Policy), args.Error(1)
 }
 
+func (m *MockAWSClient) GetRolePermissionsBoundary(ctx context.Context, roleName string) (*types.Policy, error) {
+	args := m.Called(ctx, roleName)
+	return args.Get(0).(*types.Policy), args.Error(1)
+}
+
+func (m *MockAWSClient) GetPodIdentityRole(ctx context.Context, clusterName, namespace, saName string) (string, error) {
+	args := m.Called(ctx, clusterName, namespace, saName)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAWSClient) GetInstanceProfileRole(ctx context.Context, instanceID string) (string, error) {
+	args := m.Called(ctx, instanceID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAWSClient) GetRoleARN(ctx context.Context, role string) (string, error) {
+	args := m.Called(ctx, role)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockAWSClient) GetPolicyPermissions(ctx context.Context, policyArn string) ([]types.PermissionDisplay, error) {
 	args := m.Called(ctx, policyArn)
 	return args.Get(0).([]types.PermissionDisplay), args.Error(1)
 }
 
+func (m *MockAWSClient) SimulatePrincipalPolicy(ctx context.Context, roleArn string, actions, resources []string, reqCtx map[string][]string) ([]types.SimulationResult, error) {
+	args := m.Called(ctx, roleArn, actions, resources, reqCtx)
+	return args.Get(0).([]types.SimulationResult), args.Error(1)
+}
+
+func (m *MockAWSClient) GetPolicyVersions(ctx context.Context, policyArn string) ([]types.PolicyVersion, error) {
+	args := m.Called(ctx, policyArn)
+	return args.Get(0).([]types.PolicyVersion), args.Error(1)
+}
+
+func (m *MockAWSClient) GetServiceLastAccessed(ctx context.Context, roleArn string) ([]types.ServiceUsage, error) {
+	args := m.Called(ctx, roleArn)
+	return args.Get(0).([]types.ServiceUsage), args.Error(1)
+}
+
 func TestAnalyzer_Analyze(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -68,10 +129,11 @@ func TestAnalyzer_Analyze(t *testing.T) {
 			},
 			expectedResult: []types.PodPermissions{
 				{
-					PodName:        "test-pod",
-					Namespace:      "default",
-					ServiceAccount: "test-sa",
-					IAMRole:        "test-role",
+					PodName:          "test-pod",
+					Namespace:        "default",
+					ServiceAccount:   "test-sa",
+					IAMRole:          "test-role",
+					CredentialSource: types.CredentialSourceIRSA,
 					Policies: []types.Policy{
 						{
 							Name: "test-policy",
@@ -81,6 +143,37 @@ func TestAnalyzer_Analyze(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "pod falls back to node credentials",
+			opts: &options.Options{
+				PodName:   "legacy-pod",
+				Namespace: "default",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetPod", mock.Anything, "legacy-pod", "default").Return(Pod{
+					Name: "legacy-pod",
+					Spec: PodSpec{NodeName: "node-1"},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "default").Return("", nil)
+				k8s.On("GetNodeInstanceID", mock.Anything, "node-1").Return("i-0123456789abcdef0", nil)
+				aws.On("GetInstanceProfileRole", mock.Anything, "i-0123456789abcdef0").Return("node-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "node-role").Return([]types.Policy{
+					{Name: "AmazonEKSWorkerNodePolicy", Arn: "arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy"},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					PodName:          "legacy-pod",
+					Namespace:        "default",
+					ServiceAccount:   "default",
+					IAMRole:          "node-role",
+					CredentialSource: types.CredentialSourceNode,
+					Policies: []types.Policy{
+						{Name: "AmazonEKSWorkerNodePolicy", Arn: "arn:aws:iam::aws:policy/AmazonEKSWorkerNodePolicy"},
+					},
+				},
+			},
+		},
 		{
 			name: "pod not found",
 			opts: &options.Options{
@@ -98,10 +191,559 @@ func TestAnalyzer_Analyze(t *testing.T) {
 				Namespace: "default",
 			},
 			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
-				// Currently returns empty slice as per implementation
+				k8s.On("ListPods", mock.Anything, "default", PodSelector{}).Return([]Pod{
+					{Name: "api-1", Namespace: "default", Spec: PodSpec{ServiceAccountName: "api-sa"}},
+					{Name: "api-2", Namespace: "default", Spec: PodSpec{ServiceAccountName: "api-sa"}},
+					{Name: "worker", Namespace: "default", Spec: PodSpec{ServiceAccountName: "worker-sa"}},
+					{Name: "plain", Namespace: "default"},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "api-sa").Return("shared-role", nil).Once()
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "worker-sa").Return("shared-role", nil).Once()
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "default").Return("", nil).Once()
+				aws.On("GetRolePolicies", mock.Anything, "shared-role").Return([]types.Policy{
+					{Name: "test-policy", Arn: "arn:aws:iam::test-policy"},
+				}, nil).Once()
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					PodName:          "api-1",
+					Namespace:        "default",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "shared-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+				{
+					PodName:          "api-2",
+					Namespace:        "default",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "shared-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+				{
+					PodName:          "worker",
+					Namespace:        "default",
+					ServiceAccount:   "worker-sa",
+					IAMRole:          "shared-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+				{
+					PodName:        "plain",
+					Namespace:      "default",
+					ServiceAccount: "default",
+				},
+			},
+		},
+		{
+			name: "namespace analysis with kube2iam annotations",
+			opts: &options.Options{
+				Namespace: "legacy",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				reportsAnnotations := map[string]string{"iam.amazonaws.com/role": "reports-role"}
+				k8s.On("ListPods", mock.Anything, "legacy", PodSelector{}).Return([]Pod{
+					{Name: "reports", Namespace: "legacy", Annotations: reportsAnnotations},
+					{Name: "plain", Namespace: "legacy"},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "legacy", "default").Return("", nil).Twice()
+				k8s.On("GetPodAnnotationIAMRole", mock.Anything, "legacy", reportsAnnotations).Return("reports-role", nil)
+				aws.On("GetRoleARN", mock.Anything, "reports-role").Return("arn:aws:iam::123456789012:role/reports-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "arn:aws:iam::123456789012:role/reports-role").Return([]types.Policy{
+					{Name: "test-policy", Arn: "arn:aws:iam::test-policy"},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					PodName:          "reports",
+					Namespace:        "legacy",
+					ServiceAccount:   "default",
+					IAMRole:          "arn:aws:iam::123456789012:role/reports-role",
+					CredentialSource: types.CredentialSourceKube2iam,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+				{
+					PodName:        "plain",
+					Namespace:      "legacy",
+					ServiceAccount: "default",
+				},
+			},
+		},
+		{
+			name: "namespace analysis resolves service accounts and nodes once",
+			opts: &options.Options{
+				Namespace: "batch",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("ListPods", mock.Anything, "batch", PodSelector{}).Return([]Pod{
+					{Name: "api-1", Namespace: "batch", Spec: PodSpec{ServiceAccountName: "api-sa", NodeName: "node-1"}},
+					{Name: "api-2", Namespace: "batch", Spec: PodSpec{ServiceAccountName: "api-sa", NodeName: "node-2"}},
+					{Name: "job-1", Namespace: "batch", Spec: PodSpec{NodeName: "node-1"}},
+					{Name: "job-2", Namespace: "batch", Spec: PodSpec{NodeName: "node-1"}},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "batch", "api-sa").Return("api-role", nil).Once()
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "batch", "default").Return("", nil).Once()
+				k8s.On("GetNodeInstanceID", mock.Anything, "node-1").Return("i-0123456789abcdef0", nil).Once()
+				aws.On("GetInstanceProfileRole", mock.Anything, "i-0123456789abcdef0").Return("node-role", nil).Once()
+				aws.On("GetRolePolicies", mock.Anything, "api-role").Return([]types.Policy{}, nil).Once()
+				aws.On("GetRolePolicies", mock.Anything, "node-role").Return([]types.Policy{}, nil).Once()
+			},
+			expectedResult: []types.PodPermissions{
+				{PodName: "api-1", Namespace: "batch", ServiceAccount: "api-sa", IAMRole: "api-role", CredentialSource: types.CredentialSourceIRSA, Policies: []types.Policy{}},
+				{PodName: "api-2", Namespace: "batch", ServiceAccount: "api-sa", IAMRole: "api-role", CredentialSource: types.CredentialSourceIRSA, Policies: []types.Policy{}},
+				{PodName: "job-1", Namespace: "batch", ServiceAccount: "default", IAMRole: "node-role", CredentialSource: types.CredentialSourceNode, Policies: []types.Policy{}},
+				{PodName: "job-2", Namespace: "batch", ServiceAccount: "default", IAMRole: "node-role", CredentialSource: types.CredentialSourceNode, Policies: []types.Policy{}},
+			},
+		},
+		{
+			name: "namespace analysis with lookup failures",
+			opts: &options.Options{
+				Namespace: "payments",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("ListPods", mock.Anything, "payments", PodSelector{}).Return([]Pod{
+					{Name: "api", Namespace: "payments", Spec: PodSpec{ServiceAccountName: "api-sa"}},
+					{Name: "worker", Namespace: "payments", Spec: PodSpec{ServiceAccountName: "worker-sa"}},
+					{Name: "web", Namespace: "payments", Spec: PodSpec{ServiceAccountName: "web-sa"}},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "payments", "api-sa").
+					Return("", fmt.Errorf("serviceaccounts \"api-sa\" is forbidden"))
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "payments", "worker-sa").Return("worker-role", nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "payments", "web-sa").Return("web-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "worker-role").Return([]types.Policy(nil), fmt.Errorf("access denied"))
+				aws.On("GetRolePolicies", mock.Anything, "web-role").Return([]types.Policy{
+					{Name: "test-policy", Arn: "arn:aws:iam::test-policy"},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					PodName:        "api",
+					Namespace:      "payments",
+					ServiceAccount: "api-sa",
+					Error:          "failed to resolve IAM role: serviceaccounts \"api-sa\" is forbidden",
+				},
+				{
+					PodName:          "worker",
+					Namespace:        "payments",
+					ServiceAccount:   "worker-sa",
+					IAMRole:          "worker-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Error:            "failed to get policies for role worker-role: access denied",
+				},
+				{
+					PodName:          "web",
+					Namespace:        "payments",
+					ServiceAccount:   "web-sa",
+					IAMRole:          "web-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+			},
+		},
+		{
+			name: "all namespaces analysis",
+			opts: &options.Options{
+				Namespace:     "default",
+				AllNamespaces: true,
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("ListNamespaces", mock.Anything).Return([]string{"payments", "orders"}, nil)
+				k8s.On("ListPods", mock.Anything, "payments", PodSelector{}).Return([]Pod{
+					{Name: "api", Namespace: "payments", Spec: PodSpec{ServiceAccountName: "api-sa"}},
+				}, nil)
+				k8s.On("ListPods", mock.Anything, "orders", PodSelector{}).Return([]Pod{
+					{Name: "api", Namespace: "orders", Spec: PodSpec{ServiceAccountName: "api-sa"}},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "payments", "api-sa").Return("shared-role", nil).Once()
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "orders", "api-sa").Return("shared-role", nil).Once()
+				aws.On("GetRolePolicies", mock.Anything, "shared-role").Return([]types.Policy{
+					{Name: "test-policy", Arn: "arn:aws:iam::test-policy"},
+				}, nil).Once()
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					PodName:          "api",
+					Namespace:        "payments",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "shared-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+				{
+					PodName:          "api",
+					Namespace:        "orders",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "shared-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+			},
+		},
+		{
+			name: "pod name with all namespaces",
+			opts: &options.Options{
+				PodName:       "test-pod",
+				AllNamespaces: true,
+			},
+			setupMocks:    func(k8s *MockK8sClient, aws *MockAWSClient) {},
+			expectedError: "cannot be combined with --all-namespaces",
+		},
+		{
+			name: "workload analysis",
+			opts: &options.Options{
+				WorkloadKind: "CronJob",
+				WorkloadName: "nightly-export",
+				Namespace:    "reports",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetWorkloadPodTemplate", mock.Anything, "CronJob", "nightly-export", "reports").Return(Pod{
+					Namespace: "reports",
+					Spec:      PodSpec{ServiceAccountName: "export-sa"},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "reports", "export-sa").Return("export-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "export-role").Return([]types.Policy{
+					{Name: "test-policy", Arn: "arn:aws:iam::test-policy"},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					Workload:         "CronJob/nightly-export",
+					Namespace:        "reports",
+					ServiceAccount:   "export-sa",
+					IAMRole:          "export-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+			},
+		},
+		{
+			name: "workload with default service account",
+			opts: &options.Options{
+				WorkloadKind: "Deployment",
+				WorkloadName: "api",
+				Namespace:    "default",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetWorkloadPodTemplate", mock.Anything, "Deployment", "api", "default").Return(Pod{Namespace: "default"}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "default").Return("", nil)
+			},
+			expectedError: "no IAM role found for service account default",
+		},
+		{
+			name: "service account analysis",
+			opts: &options.Options{
+				ServiceAccountName: "api-sa",
+				Namespace:          "payments",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "payments", "api-sa").Return("api-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "api-role").Return([]types.Policy{
+					{Name: "test-policy", Arn: "arn:aws:iam::test-policy"},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					Namespace:        "payments",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "api-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{{Name: "test-policy", Arn: "arn:aws:iam::test-policy"}},
+				},
+			},
+		},
+		{
+			name: "service account without role",
+			opts: &options.Options{
+				ServiceAccountName: "plain-sa",
+				Namespace:          "payments",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "payments", "plain-sa").Return("", nil)
+			},
+			expectedError: "no IAM role found for service account plain-sa",
+		},
+		{
+			name: "workload not found",
+			opts: &options.Options{
+				WorkloadKind: "Deployment",
+				WorkloadName: "missing",
+				Namespace:    "default",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetWorkloadPodTemplate", mock.Anything, "Deployment", "missing", "default").Return(Pod{}, assert.AnError)
+			},
+			expectedError: "failed to get deployment missing",
+		},
+		{
+			name: "selector analysis",
+			opts: &options.Options{
+				Namespace:     "default",
+				LabelSelector: "app=api,tier=backend",
+				FieldSelector: "spec.nodeName=node-1",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("ListPods", mock.Anything, "default", PodSelector{
+					LabelSelector: "app=api,tier=backend",
+					FieldSelector: "spec.nodeName=node-1",
+				}).Return([]Pod{
+					{Name: "api", Namespace: "default", Spec: PodSpec{ServiceAccountName: "api-sa"}},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "api-sa").Return("test-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "test-role").Return([]types.Policy{}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					PodName:          "api",
+					Namespace:        "default",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "test-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{},
+				},
+			},
+		},
+		{
+			name: "role with permissions boundary",
+			opts: &options.Options{
+				ServiceAccountName: "api-sa",
+				Namespace:          "default",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "api-sa").Return("bounded-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "bounded-role").Return([]types.Policy{
+					{
+						Name: "AmazonS3FullAccess",
+						Permissions: []types.PermissionDisplay{
+							{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true},
+						},
+					},
+				}, nil)
+				aws.On("GetRolePermissionsBoundary", mock.Anything, "bounded-role").Return(&types.Policy{
+					Name: "read-only-boundary",
+					Type: types.PolicyTypeBoundary,
+					Permissions: []types.PermissionDisplay{
+						{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+					},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					Namespace:        "default",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "bounded-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies: []types.Policy{
+						{
+							Name: "AmazonS3FullAccess",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, IsHighRisk: true, Score: 70, RiskRules: []string{"Full S3 access"}},
+							},
+							Score: 70,
+						},
+					},
+					PermissionsBoundary: &types.Policy{
+						Name: "read-only-boundary",
+						Type: types.PolicyTypeBoundary,
+						Permissions: []types.PermissionDisplay{
+							{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+						},
+					},
+					BoundedPolicies: []types.Policy{
+						{
+							Name: "AmazonS3FullAccess",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow", IsBroad: true, Score: 20},
+							},
+							Score: 20,
+						},
+					},
+					Score: 20,
+				},
+			},
+		},
+		{
+			name: "permissions narrowed by a boundary are scored again",
+			opts: &options.Options{
+				ServiceAccountName: "api-sa",
+				Namespace:          "default",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "api-sa").Return("bounded-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "bounded-role").Return([]types.Policy{
+					{
+						Name: "AmazonS3FullAccess",
+						Permissions: []types.PermissionDisplay{
+							{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true},
+						},
+					},
+				}, nil)
+				aws.On("GetRolePermissionsBoundary", mock.Anything, "bounded-role").Return(&types.Policy{
+					Name: "reports-boundary",
+					Type: types.PolicyTypeBoundary,
+					Permissions: []types.PermissionDisplay{
+						{Action: "s3:*", Resource: "arn:aws:s3:::reports/*", Effect: "Allow", IsBroad: true},
+					},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					Namespace:        "default",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "bounded-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies: []types.Policy{
+						{
+							Name: "AmazonS3FullAccess",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, IsHighRisk: true, Score: 70, RiskRules: []string{"Full S3 access"}},
+							},
+							Score: 70,
+						},
+					},
+					PermissionsBoundary: &types.Policy{
+						Name: "reports-boundary",
+						Type: types.PolicyTypeBoundary,
+						Permissions: []types.PermissionDisplay{
+							{Action: "s3:*", Resource: "arn:aws:s3:::reports/*", Effect: "Allow", IsBroad: true},
+						},
+					},
+					// No longer full S3 access, but every action on a bucket
+					BoundedPolicies: []types.Policy{
+						{
+							Name: "AmazonS3FullAccess",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:*", Resource: "arn:aws:s3:::reports/*", Effect: "Allow", IsBroad: true, IsHighRisk: true, Score: 60},
+							},
+							Score: 60,
+						},
+					},
+					Score: 60,
+				},
+			},
+		},
+		{
+			name: "can-i with simulation",
+			opts: &options.Options{
+				Command:   options.CommandCanI,
+				PodName:   "test-pod",
+				Namespace: "default",
+				Actions:   []string{"s3:GetObject"},
+				Resources: []string{"arn:aws:s3:::reports/a"},
+				Simulate:  true,
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetPod", mock.Anything, "test-pod", "default").Return(Pod{
+					Spec: PodSpec{ServiceAccountName: "test-sa"},
+				}, nil)
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").Return("test-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "test-role").Return([]types.Policy{}, nil)
+				aws.On("SimulatePrincipalPolicy", mock.Anything, "test-role",
+					[]string{"s3:GetObject"}, []string{"arn:aws:s3:::reports/a"}, map[string][]string(nil)).Return([]types.SimulationResult{
+					{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a", Decision: "implicitly denied"},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					PodName:          "test-pod",
+					Namespace:        "default",
+					ServiceAccount:   "test-sa",
+					IAMRole:          "test-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies:         []types.Policy{},
+					Simulation: []types.SimulationResult{
+						{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a", Decision: "implicitly denied"},
+					},
+				},
+			},
+		},
+		{
+			name: "conditions resolved against the request context",
+			opts: &options.Options{
+				ServiceAccountName: "api-sa",
+				Namespace:          "default",
+				Context:            map[string][]string{"aws:SourceVpc": {"vpc-123"}},
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "api-sa").Return("api-role", nil)
+				aws.On("GetRolePolicies", mock.Anything, "api-role").Return([]types.Policy{
+					{
+						Name: "vpc-only",
+						Permissions: []types.PermissionDisplay{
+							{Action: "s3:PutObject", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: []types.Condition{
+								{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}},
+							}},
+							{Action: "s3:*", Resource: "*", Effect: "Deny", HasCondition: true, Conditions: []types.Condition{
+								{Operator: "Bool", Key: "aws:SecureTransport", Values: []string{"false"}},
+							}},
+						},
+					},
+				}, nil)
+			},
+			expectedResult: []types.PodPermissions{
+				{
+					Namespace:        "default",
+					ServiceAccount:   "api-sa",
+					IAMRole:          "api-role",
+					CredentialSource: types.CredentialSourceIRSA,
+					Policies: []types.Policy{
+						{
+							Name: "vpc-only",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:PutObject", Resource: "*", Effect: "Allow", IsBroad: true, HasCondition: true, ConditionResult: types.ConditionsHold, Score: 45, Conditions: []types.Condition{
+									{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}},
+								}},
+								// aws:SecureTransport is absent from the request, so the Deny overrides nothing
+								{Action: "s3:*", Resource: "*", Effect: "Deny", IsBroad: true, HasCondition: true, ConditionResult: types.ConditionsFail, Conditions: []types.Condition{
+									{Operator: "Bool", Key: "aws:SecureTransport", Values: []string{"false"}},
+								}},
+							},
+							Score: 45,
+						},
+					},
+					Score: 45,
+				},
+			},
+		},
+		{
+			name: "missing risk rules file",
+			opts: &options.Options{
+				PodName:   "test-pod",
+				Namespace: "default",
+				RulesFile: "/nonexistent/rules.yaml",
+			},
+			setupMocks:    func(k8s *MockK8sClient, aws *MockAWSClient) {},
+			expectedError: "failed to read risk rules",
+		},
+		{
+			name: "pod name with selector",
+			opts: &options.Options{
+				PodName:       "test-pod",
+				LabelSelector: "app=api",
+			},
+			setupMocks:    func(k8s *MockK8sClient, aws *MockAWSClient) {},
+			expectedError: "cannot be combined with a label or field selector",
+		},
+		{
+			name: "empty namespace",
+			opts: &options.Options{
+				Namespace: "empty",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("ListPods", mock.Anything, "empty", PodSelector{}).Return([]Pod{}, nil)
 			},
 			expectedResult: []types.PodPermissions{},
 		},
+		{
+			name: "namespace listing fails",
+			opts: &options.Options{
+				Namespace: "default",
+			},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("ListPods", mock.Anything, "default", PodSelector{}).Return([]Pod{}, assert.AnError)
+			},
+			expectedError: "failed to list pods in namespace default",
+		},
 	}
 
 	for _, tt := range tests {
@@ -109,6 +751,8 @@ func TestAnalyzer_Analyze(t *testing.T) {
 			mockK8s := new(MockK8sClient)
 			mockAWS := new(MockAWSClient)
 			tt.setupMocks(mockK8s, mockAWS)
+			// Roles have no permissions boundary unless a case sets one up
+			mockAWS.On("GetRolePermissionsBoundary", mock.Anything, mock.Anything).Return((*types.Policy)(nil), nil).Maybe()
 
 			analyzer := New(mockK8s, mockAWS)
 			result, err := analyzer.Analyze(tt.opts)
@@ -151,6 +795,8 @@ func TestAnalyzePod(t *testing.T) {
 		},
 	}, nil)
 
+	aws.On("GetRolePermissionsBoundary", mock.Anything, "test-role").Return((*types.Policy)(nil), nil)
+
 	aws.On("GetPolicyPermissions", mock.Anything, "arn:aws:iam::123456789012:policy/test-policy").Return([]types.PermissionDisplay{
 		{
 			Action:   "s3:GetObject",
@@ -171,3 +817,48 @@ func TestAnalyzePod(t *testing.T) {
 	assert.Len(t, pod.Policies, 1)
 	assert.Equal(t, "test-policy", pod.Policies[0].Name)
 }
+
+func TestRoleAccess_EscalationPaths(t *testing.T) {
+	access := roleAccess{
+		policies: []types.Policy{
+			{Name: "deployer", Permissions: []types.PermissionDisplay{
+				{Action: "iam:PassRole", Resource: "*", Effect: "Allow"},
+				{Action: "ec2:RunInstances", Resource: "*", Effect: "Allow"},
+			}},
+		},
+	}
+
+	var podPerms types.PodPermissions
+	access.apply(&podPerms, nil)
+	assert.Len(t, podPerms.EscalationPaths, 1)
+	assert.Equal(t, "iam:PassRole + ec2:RunInstances", podPerms.EscalationPaths[0].Path)
+
+	// A permissions boundary not allowing iam:PassRole closes the path
+	access.boundary = &types.Policy{Name: "boundary", Type: types.PolicyTypeBoundary, Permissions: []types.PermissionDisplay{
+		{Action: "ec2:*", Resource: "*", Effect: "Allow"},
+	}}
+	podPerms = types.PodPermissions{}
+	access.apply(&podPerms, nil)
+	assert.Empty(t, podPerms.EscalationPaths)
+}
+
+func TestAnalyzeAllNamespaces_Deadlines(t *testing.T) {
+	mockK8s := new(MockK8sClient)
+	analyzer := New(mockK8s, new(MockAWSClient))
+
+	// Every namespace is scanned against a deadline of its own
+	scans := make(map[string]context.Context)
+	record := func(args mock.Arguments) { scans[args.String(1)] = args.Get(0).(context.Context) }
+	mockK8s.On("ListNamespaces", mock.Anything).Return([]string{"payments", "orders"}, nil)
+	mockK8s.On("ListPods", mock.Anything, "payments", PodSelector{}).Run(record).Return([]Pod{}, nil)
+	mockK8s.On("ListPods", mock.Anything, "orders", PodSelector{}).Run(record).Return([]Pod{}, nil)
+
+	_, err := analyzer.Analyze(&options.Options{AllNamespaces: true})
+	assert.NoError(t, err)
+	assert.Len(t, scans, 2)
+	for namespace, ctx := range scans {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok, namespace)
+	}
+	assert.NotSame(t, scans["payments"], scans["orders"])
+}
@@ -0,0 +1,172 @@
+package analyzer
+
+import (
+	"reflect"
+
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// applyBoundary restricts each policy to the permissions its role's
+// permissions boundary also allows. An allowed action or resource is narrowed
+// to the more specific of the two patterns, so s3:* under a boundary allowing
+// s3:GetObject becomes s3:GetObject, and partially overlapping action
+// patterns to the catalogued actions both match. Permissions matched by a
+// Deny in the boundary are dropped.
+func applyBoundary(policies []types.Policy, boundary types.Policy) []types.Policy {
+	bounded := make([]types.Policy, 0, len(policies))
+
+	for _, policy := range policies {
+		var perms []types.PermissionDisplay
+
+		for _, p := range policy.Permissions {
+			// Denies restrict no matter what the boundary allows
+			if p.Effect != "Allow" {
+				if !containsPermission(perms, p) {
+					perms = append(perms, p)
+				}
+				continue
+			}
+
+			if deniedByBoundary(p, boundary) {
+				continue
+			}
+
+			for _, b := range boundary.Permissions {
+				if b.Effect != "Allow" {
+					continue
+				}
+
+				for _, narrowed := range intersectPermission(p, b) {
+					narrowed.HasCondition = p.HasCondition || b.HasCondition
+					// Both the grant's and the boundary's conditions must hold
+					if len(b.Conditions) > 0 {
+						narrowed.Conditions = append(append([]types.Condition{}, p.Conditions...), b.Conditions...)
+					}
+
+					if !containsPermission(perms, narrowed) {
+						perms = append(perms, narrowed)
+					}
+				}
+			}
+		}
+
+		policy.Permissions = perms
+		bounded = append(bounded, policy)
+	}
+
+	return bounded
+}
+
+func containsPermission(perms []types.PermissionDisplay, p types.PermissionDisplay) bool {
+	for _, existing := range perms {
+		if reflect.DeepEqual(existing, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// deniedByBoundary reports whether a Deny in the boundary that applies
+// unconditionally, or whose conditions hold in the request context, covers
+// the whole permission
+func deniedByBoundary(p types.PermissionDisplay, boundary types.Policy) bool {
+	for _, b := range boundary.Permissions {
+		if b.Effect != "Deny" || b.IsConditional() {
+			continue
+		}
+		if evaluator.Covers(b, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// intersectPermission narrows a permission to what the boundary permission
+// also allows, returning nothing when they have nothing in common. NotAction
+// and NotResource exclusions cannot be narrowed pattern by pattern, so the
+// permission that covers the other one is kept, and on a partial overlap the
+// permission is kept as is.
+func intersectPermission(p, b types.PermissionDisplay) []types.PermissionDisplay {
+	if p.NotAction || p.NotResource || b.NotAction || b.NotResource {
+		switch {
+		case evaluator.Covers(b, p):
+			return []types.PermissionDisplay{p}
+		case evaluator.Covers(p, b):
+			narrowed := p
+			narrowed.Action, narrowed.NotAction = b.Action, b.NotAction
+			narrowed.Resource, narrowed.NotResource = b.Resource, b.NotResource
+			return []types.PermissionDisplay{narrowed}
+		case evaluator.Overlaps(p, b):
+			return []types.PermissionDisplay{p}
+		}
+		return nil
+	}
+
+	resource, ok := intersectResources(p.Resource, b.Resource)
+	if !ok {
+		return nil
+	}
+
+	var intersection []types.PermissionDisplay
+	for _, action := range intersectActions(p.Action, b.Action) {
+		narrowed := p
+		narrowed.Action = action
+		narrowed.Resource = resource
+		intersection = append(intersection, narrowed)
+	}
+	return intersection
+}
+
+// intersectActions returns the action patterns both a and b allow: the more
+// specific one when one covers the other, and otherwise the catalogued
+// actions they both match, so s3:Get* and s3:*Object share s3:GetObject. On a
+// partial overlap involving services missing from the catalog, a is kept as
+// is.
+func intersectActions(a, b string) []string {
+	if pattern, ok := intersectPattern(a, b, evaluator.MatchAction); ok {
+		return []string{pattern}
+	}
+	if !evaluator.OverlapAction(a, b) {
+		return nil
+	}
+
+	actions, known := catalog.Default().Expand(a)
+	if !known {
+		return []string{a}
+	}
+	var shared []string
+	for _, action := range actions {
+		if evaluator.MatchAction(b, action.Name) {
+			shared = append(shared, action.Name)
+		}
+	}
+	return shared
+}
+
+// intersectResources returns the more specific of two resource patterns when
+// one covers the other. Partially overlapping patterns, such as
+// arn:aws:s3:::bucket/* and arn:aws:s3:::*/reports/*, rarely have an
+// intersection that a single pattern can describe, so a is kept as is.
+func intersectResources(a, b string) (string, bool) {
+	if pattern, ok := intersectPattern(a, b, evaluator.MatchResource); ok {
+		return pattern, true
+	}
+	if evaluator.OverlapResource(a, b) {
+		return a, true
+	}
+	return "", false
+}
+
+// intersectPattern returns the more specific of two IAM wildcard patterns
+// when one of them covers the other
+func intersectPattern(a, b string, match func(pattern, value string) bool) (string, bool) {
+	if match(b, a) {
+		return a, true
+	}
+	if match(a, b) {
+		return b, true
+	}
+	return "", false
+}
@@ -0,0 +1,182 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyBoundary(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   []types.PermissionDisplay
+		boundary []types.PermissionDisplay
+		want     []types.PermissionDisplay
+	}{
+		{
+			name: "broad grant narrowed to boundary",
+			policy: []types.PermissionDisplay{
+				{Action: "s3:*", Resource: "*", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+			},
+			want: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+			},
+		},
+		{
+			name: "grant inside a broad boundary is kept",
+			policy: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/2024", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "s3:*", Resource: "*", Effect: "Allow"},
+			},
+			want: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/2024", Effect: "Allow"},
+			},
+		},
+		{
+			name: "service outside the boundary is dropped",
+			policy: []types.PermissionDisplay{
+				{Action: "iam:CreateRole", Resource: "*", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "s3:*", Resource: "*", Effect: "Allow"},
+			},
+			want: nil,
+		},
+		{
+			name: "NotAction grant narrowed to boundary",
+			policy: []types.PermissionDisplay{
+				{Action: "iam:*", Resource: "*", Effect: "Allow", NotAction: true},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "s3:*", Resource: "*", Effect: "Allow"},
+			},
+			want: []types.PermissionDisplay{
+				{Action: "s3:*", Resource: "*", Effect: "Allow"},
+			},
+		},
+		{
+			name: "partially overlapping actions narrowed to the actions both allow",
+			policy: []types.PermissionDisplay{
+				{Action: "s3:Put*Tagging", Resource: "arn:aws:s3:::reports/2024", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "s3:PutObject*", Resource: "*", Effect: "Allow"},
+			},
+			want: []types.PermissionDisplay{
+				{Action: "s3:PutObjectTagging", Resource: "arn:aws:s3:::reports/2024", Effect: "Allow"},
+				{Action: "s3:PutObjectVersionTagging", Resource: "arn:aws:s3:::reports/2024", Effect: "Allow"},
+			},
+		},
+		{
+			name: "partially overlapping action narrowed to a single action",
+			policy: []types.PermissionDisplay{
+				{Action: "s3:Get*", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "s3:*Object", Resource: "*", Effect: "Allow"},
+			},
+			want: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+			},
+		},
+		{
+			name: "overlapping patterns without common catalogued actions are dropped",
+			policy: []types.PermissionDisplay{
+				{Action: "s3:List*", Resource: "*", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "s3:*Policy", Resource: "*", Effect: "Allow"},
+			},
+			want: nil,
+		},
+		{
+			name: "partially overlapping actions of an uncatalogued service are kept",
+			policy: []types.PermissionDisplay{
+				{Action: "examplesvc:Get*", Resource: "*", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "examplesvc:*Item", Resource: "*", Effect: "Allow"},
+			},
+			want: []types.PermissionDisplay{
+				{Action: "examplesvc:Get*", Resource: "*", Effect: "Allow"},
+			},
+		},
+		{
+			name: "partially overlapping resources are kept",
+			policy: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::bucket/*", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::*/reports/*", Effect: "Allow"},
+			},
+			want: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::bucket/*", Effect: "Allow"},
+			},
+		},
+		{
+			name: "boundary deny removes grant",
+			policy: []types.PermissionDisplay{
+				{Action: "s3:DeleteObject", Resource: "arn:aws:s3:::reports/2024", Effect: "Allow"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "*", Resource: "*", Effect: "Allow"},
+				{Action: "s3:Delete*", Resource: "*", Effect: "Deny"},
+			},
+			want: nil,
+		},
+		{
+			name: "conditions of grant and boundary both apply",
+			policy: []types.PermissionDisplay{
+				{
+					Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/2024", Effect: "Allow", HasCondition: true,
+					Conditions: []types.Condition{{Operator: "StringEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1"}}},
+				},
+			},
+			boundary: []types.PermissionDisplay{
+				{
+					Action: "s3:*", Resource: "*", Effect: "Allow", HasCondition: true,
+					Conditions: []types.Condition{{Operator: "StringEquals", Key: "aws:RequestedRegion", Values: []string{"eu-west-1"}}},
+				},
+			},
+			want: []types.PermissionDisplay{
+				{
+					Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/2024", Effect: "Allow", HasCondition: true,
+					Conditions: []types.Condition{
+						{Operator: "StringEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1"}},
+						{Operator: "StringEquals", Key: "aws:RequestedRegion", Values: []string{"eu-west-1"}},
+					},
+				},
+			},
+		},
+		{
+			name: "policy deny is kept",
+			policy: []types.PermissionDisplay{
+				{Action: "s3:DeleteBucket", Resource: "*", Effect: "Deny"},
+			},
+			boundary: []types.PermissionDisplay{
+				{Action: "ec2:Describe*", Resource: "*", Effect: "Allow"},
+			},
+			want: []types.PermissionDisplay{
+				{Action: "s3:DeleteBucket", Resource: "*", Effect: "Deny"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyBoundary(
+				[]types.Policy{{Name: "policy", Permissions: tt.policy}},
+				types.Policy{Name: "boundary", Permissions: tt.boundary},
+			)
+			assert.Len(t, got, 1)
+			assert.Equal(t, "policy", got[0].Name)
+			assert.Equal(t, tt.want, got[0].Permissions)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// history fetches the versions of the named managed policy for each pod with
+// an IAM role. Pods whose roles share the policy share its versions.
+func (a *Analyzer) history(ctx context.Context, perms []types.PodPermissions, policyName string) error {
+	names := make(map[string]string)
+
+	return forEachRole(ctx, perms,
+		func(perm types.PodPermissions) (string, error) {
+			policy, ok := findPolicy(perm.Policies, policyName)
+			if !ok {
+				return "", fmt.Errorf("role %s has no policy named %s", perm.IAMRole, policyName)
+			}
+			if policy.Arn == "" {
+				return "", fmt.Errorf("policy %s is an inline policy, which has no versions", policy.Name)
+			}
+			names[policy.Arn] = policy.Name
+			return policy.Arn, nil
+		},
+		func(ctx context.Context, arn string) ([]types.PolicyVersion, error) {
+			history, err := a.awsClient.GetPolicyVersions(ctx, arn)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get versions of policy %s: %v", names[arn], err)
+			}
+			return history, nil
+		},
+		func(perm *types.PodPermissions, history []types.PolicyVersion) {
+			perm.PolicyHistory = history
+		})
+}
+
+// findPolicy looks up a policy by name or ARN
+func findPolicy(policies []types.Policy, name string) (types.Policy, bool) {
+	for _, policy := range policies {
+		if policy.Name == name || (policy.Arn != "" && policy.Arn == name) {
+			return policy, true
+		}
+	}
+	return types.Policy{}, false
+}
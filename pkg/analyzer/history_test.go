@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHistory(t *testing.T) {
+	policyArn := "arn:aws:iam::123456789012:policy/reports-writer"
+	policies := []types.Policy{
+		{Name: "reports-writer", Arn: policyArn},
+		{Name: "inline-extras", Type: types.PolicyTypeInline},
+	}
+	versions := []types.PolicyVersion{
+		{VersionID: "v2", CreateDate: time.Date(2024, 5, 2, 10, 14, 0, 0, time.UTC), IsDefault: true},
+		{VersionID: "v1", CreateDate: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name          string
+		policyName    string
+		err           error
+		expectedError string
+	}{
+		{
+			name:       "policy named by name",
+			policyName: "reports-writer",
+		},
+		{
+			name:       "policy named by ARN",
+			policyName: policyArn,
+		},
+		{
+			name:          "inline policies have no versions",
+			policyName:    "inline-extras",
+			expectedError: "policy inline-extras is an inline policy, which has no versions",
+		},
+		{
+			name:          "policy not attached to the role",
+			policyName:    "missing",
+			expectedError: "role api-role has no policy named missing",
+		},
+		{
+			name:          "versions cannot be read",
+			policyName:    "reports-writer",
+			err:           assert.AnError,
+			expectedError: "failed to get versions of policy reports-writer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aws := &MockAWSClient{}
+			aws.On("GetPolicyVersions", mock.Anything, policyArn).Return(versions, tt.err).Maybe()
+
+			perms := []types.PodPermissions{{PodName: "api", IAMRole: "api-role", Policies: policies}}
+			err := New(&MockK8sClient{}, aws).history(context.Background(), perms, tt.policyName)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, versions, perms[0].PolicyHistory)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package analyzer
+
+import (
+	"github.com/berkguzel/pperm/pkg/risk"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// scoreRisk scores the policies of each pod against the risk rules, and each
+// pod by what its role is effectively granted
+func scoreRisk(perms []types.PodPermissions, rules []risk.Rule) {
+	for i := range perms {
+		perm := &perms[i]
+		if perm.IAMRole == "" {
+			continue
+		}
+
+		account := risk.AccountID(perm.IAMRole)
+		perm.Policies = risk.ScorePolicies(perm.Policies, account, rules)
+		effective := perm.Policies
+		if perm.PermissionsBoundary != nil {
+			perm.BoundedPolicies = risk.ScorePolicies(perm.BoundedPolicies, account, rules)
+			effective = perm.BoundedPolicies
+		}
+		perm.Score = risk.PodScore(effective, perm.EscalationPaths)
+	}
+}
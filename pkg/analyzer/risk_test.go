@@ -0,0 +1,34 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/risk"
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScoreRisk(t *testing.T) {
+	reports := types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"}
+	perms := []types.PodPermissions{
+		{
+			PodName:  "api",
+			IAMRole:  "arn:aws:iam::123456789012:role/api",
+			Policies: []types.Policy{{Name: "reports", Permissions: []types.PermissionDisplay{reports}}},
+		},
+		{PodName: "worker"},
+	}
+	rules := []risk.Rule{{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Severity: risk.SeverityCritical, Description: "Reads the quarterly reports"}}
+
+	scoreRisk(perms, rules)
+
+	scored := perms[0].Policies[0].Permissions[0]
+	assert.Equal(t, 80, scored.Score)
+	assert.Equal(t, []string{"Reads the quarterly reports"}, scored.RiskRules)
+	assert.Equal(t, 80, perms[0].Policies[0].Score)
+	assert.Equal(t, 80, perms[0].Score)
+
+	// Pods without a role are left unscored
+	assert.Nil(t, perms[1].Policies)
+	assert.Equal(t, 0, perms[1].Score)
+}
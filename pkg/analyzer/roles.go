@@ -0,0 +1,160 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// podRoleAnnotation is the pod annotation kube2iam and kiam read the
+// requested role from
+const podRoleAnnotation = "iam.amazonaws.com/role"
+
+// errNoIAMRole is returned by resolveRole for pods that have no IAM role
+// configured, as opposed to pods whose role could not be looked up
+var errNoIAMRole = errors.New("no IAM role configured")
+
+// roleBinding is an IAM role together with the mechanism that grants it
+type roleBinding struct {
+	role   string
+	source string
+}
+
+// roleResolution is the outcome of looking up a role, cached during scans
+type roleResolution struct {
+	binding roleBinding
+	err     error
+}
+
+// resolveRole finds the IAM role a pod runs with. Mechanisms are tried in
+// the order the AWS SDK default credential chain picks them up: IRSA (web
+// identity), then EKS Pod Identity (container credentials), then kube2iam and
+// kiam, which intercept the instance metadata endpoint. Pods that have none
+// of these reach the metadata endpoint directly and get the credentials of
+// the node's instance profile. Pod Identity associations can only be looked
+// up when the cluster name is known, and node credentials only for pods
+// scheduled on a node.
+func (a *Analyzer) resolveRole(ctx context.Context, namespace string, pod Pod, opts *options.Options) (roleBinding, error) {
+	binding, err := a.resolvePodRole(ctx, namespace, pod, opts)
+	if !usesNodeRole(pod, err) {
+		return binding, err
+	}
+	return a.resolveNodeRole(ctx, pod.Spec.NodeName)
+}
+
+// resolvePodRole finds the IAM role a pod is configured with through IRSA,
+// Pod Identity or kube2iam and kiam, which only depends on its service
+// account and role annotation
+func (a *Analyzer) resolvePodRole(ctx context.Context, namespace string, pod Pod, opts *options.Options) (roleBinding, error) {
+	saName := pod.Spec.ServiceAccountName
+	var errs []string
+
+	iamRole, err := a.k8sClient.GetServiceAccountIAMRole(ctx, namespace, saName)
+	if err != nil {
+		errs = append(errs, err.Error())
+	} else if iamRole != "" {
+		return roleBinding{role: iamRole, source: types.CredentialSourceIRSA}, nil
+	}
+
+	if opts.ClusterName != "" {
+		iamRole, err = a.awsClient.GetPodIdentityRole(ctx, opts.ClusterName, namespace, saName)
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else if iamRole != "" {
+			return roleBinding{role: iamRole, source: types.CredentialSourcePodIdentity}, nil
+		}
+	}
+
+	if _, ok := pod.Annotations[podRoleAnnotation]; ok {
+		iamRole, err := a.k8sClient.GetPodAnnotationIAMRole(ctx, namespace, pod.Annotations)
+		// kube2iam and kiam accept role names relative to the account,
+		// while the simulator and last-accessed reports need the ARN
+		if err == nil && iamRole != "" && !strings.HasPrefix(iamRole, "arn:") {
+			iamRole, err = a.awsClient.GetRoleARN(ctx, iamRole)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		} else if iamRole != "" {
+			return roleBinding{role: iamRole, source: types.CredentialSourceKube2iam}, nil
+		}
+	}
+
+	if len(errs) > 0 {
+		return roleBinding{}, errors.New(strings.Join(errs, "; "))
+	}
+	return roleBinding{}, errNoIAMRole
+}
+
+// usesNodeRole reports whether a pod gets the credentials of its node, given
+// the outcome of resolvePodRole. The node is only assumed when no mechanism
+// is configured and every lookup succeeded: a failed lookup may hide a
+// mechanism, and kube2iam and kiam keep pods away from the node credentials
+// even when they refuse the role.
+func usesNodeRole(pod Pod, err error) bool {
+	_, annotated := pod.Annotations[podRoleAnnotation]
+	return errors.Is(err, errNoIAMRole) && !annotated && pod.Spec.NodeName != ""
+}
+
+// resolveNodeRole returns the instance profile role of the EC2 instance
+// backing the node. Nodes that are not EC2 instances, such as Fargate nodes,
+// and instances without a profile have no role.
+func (a *Analyzer) resolveNodeRole(ctx context.Context, nodeName string) (roleBinding, error) {
+	instanceID, err := a.k8sClient.GetNodeInstanceID(ctx, nodeName)
+	if err != nil {
+		return roleBinding{}, fmt.Errorf("failed to get instance of node %s: %v", nodeName, err)
+	}
+	if instanceID == "" {
+		return roleBinding{}, errNoIAMRole
+	}
+
+	iamRole, err := a.awsClient.GetInstanceProfileRole(ctx, instanceID)
+	if err != nil {
+		return roleBinding{}, err
+	}
+	if iamRole == "" {
+		return roleBinding{}, errNoIAMRole
+	}
+	return roleBinding{role: iamRole, source: types.CredentialSourceNode}, nil
+}
+
+// byRole keys the pods sharing an IAM role together for forEachRole
+func byRole(perm types.PodPermissions) (string, error) {
+	return perm.IAMRole, nil
+}
+
+// forEachRole fills in every pod with an IAM role from fetch. Pods are
+// grouped by key, so fetch runs once for the pods sharing a role, or a
+// policy, each time with a deadline of its own. The first error from key or
+// fetch stops it.
+func forEachRole[T any](ctx context.Context, perms []types.PodPermissions, key func(types.PodPermissions) (string, error), fetch func(ctx context.Context, key string) (T, error), set func(*types.PodPermissions, T)) error {
+	results := make(map[string]T)
+
+	for i := range perms {
+		if perms[i].IAMRole == "" {
+			continue
+		}
+
+		k, err := key(perms[i])
+		if err != nil {
+			return err
+		}
+
+		result, ok := results[k]
+		if !ok {
+			fetchCtx, cancel := context.WithTimeout(ctx, roleTimeout)
+			result, err = fetch(fetchCtx, k)
+			cancel()
+			if err != nil {
+				return err
+			}
+			results[k] = result
+		}
+		set(&perms[i], result)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResolveRole(t *testing.T) {
+	tests := []struct {
+		name          string
+		clusterName   string
+		annotations   map[string]string
+		nodeName      string
+		setupMocks    func(*MockK8sClient, *MockAWSClient)
+		expected      roleBinding
+		expectedError string
+	}{
+		{
+			name:        "IRSA annotation takes precedence",
+			clusterName: "prod",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").Return("irsa-role", nil)
+			},
+			expected: roleBinding{role: "irsa-role", source: types.CredentialSourceIRSA},
+		},
+		{
+			name:        "falls back to pod identity association",
+			clusterName: "prod",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").
+					Return("", nil)
+				aws.On("GetPodIdentityRole", mock.Anything, "prod", "default", "test-sa").Return("pod-identity-role", nil)
+			},
+			expected: roleBinding{role: "pod-identity-role", source: types.CredentialSourcePodIdentity},
+		},
+		{
+			name:        "no annotation and no association",
+			clusterName: "prod",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").
+					Return("", nil)
+				aws.On("GetPodIdentityRole", mock.Anything, "prod", "default", "test-sa").
+					Return("", nil)
+			},
+			expectedError: "no IAM role configured",
+		},
+		{
+			name:        "falls back to kube2iam annotation",
+			annotations: map[string]string{"iam.amazonaws.com/role": "arn:aws:iam::123456789012:role/legacy-role"},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").
+					Return("", nil)
+				k8s.On("GetPodAnnotationIAMRole", mock.Anything, "default", map[string]string{"iam.amazonaws.com/role": "arn:aws:iam::123456789012:role/legacy-role"}).
+					Return("arn:aws:iam::123456789012:role/legacy-role", nil)
+			},
+			expected: roleBinding{role: "arn:aws:iam::123456789012:role/legacy-role", source: types.CredentialSourceKube2iam},
+		},
+		{
+			name:        "kube2iam role named without its ARN",
+			annotations: map[string]string{"iam.amazonaws.com/role": "legacy-role"},
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").Return("", nil)
+				k8s.On("GetPodAnnotationIAMRole", mock.Anything, "default", map[string]string{"iam.amazonaws.com/role": "legacy-role"}).
+					Return("legacy-role", nil)
+				aws.On("GetRoleARN", mock.Anything, "legacy-role").Return("arn:aws:iam::123456789012:role/legacy/legacy-role", nil)
+			},
+			expected: roleBinding{role: "arn:aws:iam::123456789012:role/legacy/legacy-role", source: types.CredentialSourceKube2iam},
+		},
+		{
+			name:        "kube2iam role that does not exist",
+			annotations: map[string]string{"iam.amazonaws.com/role": "legacy-role"},
+			nodeName:    "node-1",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").Return("", nil)
+				k8s.On("GetPodAnnotationIAMRole", mock.Anything, "default", map[string]string{"iam.amazonaws.com/role": "legacy-role"}).
+					Return("legacy-role", nil)
+				aws.On("GetRoleARN", mock.Anything, "legacy-role").Return("", fmt.Errorf("failed to get role: NoSuchEntity"))
+			},
+			expectedError: "failed to get role",
+		},
+		{
+			name:        "kiam role not permitted",
+			annotations: map[string]string{"iam.amazonaws.com/role": "admin-role"},
+			nodeName:    "node-1",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").
+					Return("", nil)
+				k8s.On("GetPodAnnotationIAMRole", mock.Anything, "default", map[string]string{"iam.amazonaws.com/role": "admin-role"}).
+					Return("", fmt.Errorf("role admin-role is not permitted in namespace default"))
+			},
+			expectedError: "role admin-role is not permitted in namespace default",
+		},
+		{
+			name:     "falls back to node credentials",
+			nodeName: "node-1",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").
+					Return("", nil)
+				k8s.On("GetNodeInstanceID", mock.Anything, "node-1").Return("i-0123456789abcdef0", nil)
+				aws.On("GetInstanceProfileRole", mock.Anything, "i-0123456789abcdef0").Return("node-role", nil)
+			},
+			expected: roleBinding{role: "node-role", source: types.CredentialSourceNode},
+		},
+		{
+			name:     "service account lookup fails",
+			nodeName: "node-1",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").
+					Return("", fmt.Errorf("serviceaccounts \"test-sa\" is forbidden"))
+			},
+			expectedError: "serviceaccounts \"test-sa\" is forbidden",
+		},
+		{
+			name:        "pod identity lookup fails",
+			clusterName: "prod",
+			nodeName:    "node-1",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").Return("", nil)
+				aws.On("GetPodIdentityRole", mock.Anything, "prod", "default", "test-sa").
+					Return("", fmt.Errorf("failed to list pod identity associations: access denied"))
+			},
+			expectedError: "failed to list pod identity associations",
+		},
+		{
+			name:     "node is not an EC2 instance",
+			nodeName: "fargate-ip-10-0-1-24",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").Return("", nil)
+				k8s.On("GetNodeInstanceID", mock.Anything, "fargate-ip-10-0-1-24").Return("", nil)
+			},
+			expectedError: "no IAM role configured",
+		},
+		{
+			name:     "node without instance profile",
+			nodeName: "node-1",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").Return("", nil)
+				k8s.On("GetNodeInstanceID", mock.Anything, "node-1").Return("i-0123456789abcdef0", nil)
+				aws.On("GetInstanceProfileRole", mock.Anything, "i-0123456789abcdef0").Return("", nil)
+			},
+			expectedError: "no IAM role configured",
+		},
+		{
+			name:     "node lookup fails",
+			nodeName: "node-1",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").Return("", nil)
+				k8s.On("GetNodeInstanceID", mock.Anything, "node-1").Return("", fmt.Errorf("nodes \"node-1\" is forbidden"))
+			},
+			expectedError: "failed to get instance of node node-1",
+		},
+		{
+			name: "pod identity skipped without cluster name",
+			setupMocks: func(k8s *MockK8sClient, aws *MockAWSClient) {
+				k8s.On("GetServiceAccountIAMRole", mock.Anything, "default", "test-sa").
+					Return("", nil)
+			},
+			expectedError: "no IAM role configured",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockK8s := new(MockK8sClient)
+			mockAWS := new(MockAWSClient)
+			tt.setupMocks(mockK8s, mockAWS)
+
+			analyzer := New(mockK8s, mockAWS)
+			pod := Pod{
+				Annotations: tt.annotations,
+				Spec:        PodSpec{ServiceAccountName: "test-sa", NodeName: tt.nodeName},
+			}
+			binding, err := analyzer.resolveRole(context.Background(), "default", pod,
+				&options.Options{ClusterName: tt.clusterName})
+
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, binding)
+			}
+
+			mockK8s.AssertExpectations(t)
+			mockAWS.AssertExpectations(t)
+		})
+	}
+}
+
+func TestForEachRole(t *testing.T) {
+	perms := []types.PodPermissions{
+		{PodName: "api-1", IAMRole: "api-role"},
+		{PodName: "api-2", IAMRole: "api-role"},
+		{PodName: "worker", IAMRole: "worker-role"},
+		{PodName: "batch"},
+	}
+
+	var fetched []string
+	fetch := func(ctx context.Context, role string) (string, error) {
+		// Every fetch runs against a deadline of its own
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		fetched = append(fetched, role)
+		return "report of " + role, nil
+	}
+	reports := make(map[string]string)
+	set := func(perm *types.PodPermissions, report string) { reports[perm.PodName] = report }
+
+	// Pods sharing a key share one fetch, pods without a role are skipped
+	err := forEachRole(context.Background(), perms, byRole, fetch, set)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api-role", "worker-role"}, fetched)
+	assert.Equal(t, map[string]string{
+		"api-1":  "report of api-role",
+		"api-2":  "report of api-role",
+		"worker": "report of worker-role",
+	}, reports)
+
+	// Errors from key and fetch are returned as they are
+	refuse := func(perm types.PodPermissions) (string, error) {
+		return "", fmt.Errorf("pod %s refused", perm.PodName)
+	}
+	err = forEachRole(context.Background(), perms, refuse, fetch, set)
+	assert.EqualError(t, err, "pod api-1 refused")
+
+	fail := func(ctx context.Context, role string) (string, error) {
+		return "", fmt.Errorf("failed to fetch %s", role)
+	}
+	err = forEachRole(context.Background(), perms, byRole, fail, set)
+	assert.EqualError(t, err, "failed to fetch api-role")
+}
@@ -0,0 +1,25 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// simulate asks the AWS IAM policy simulator for the decision on every
+// action and resource for each pod with an IAM role, in the request context
+// given on the command line. Pods sharing a role share the simulation.
+func (a *Analyzer) simulate(ctx context.Context, perms []types.PodPermissions, actions, resources []string, reqCtx map[string][]string) error {
+	return forEachRole(ctx, perms, byRole,
+		func(ctx context.Context, role string) ([]types.SimulationResult, error) {
+			results, err := a.awsClient.SimulatePrincipalPolicy(ctx, role, actions, resources, reqCtx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to simulate policies of role %s: %v", role, err)
+			}
+			return results, nil
+		},
+		func(perm *types.PodPermissions, results []types.SimulationResult) {
+			perm.Simulation = results
+		})
+}
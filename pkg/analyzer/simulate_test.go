@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSimulate(t *testing.T) {
+	actions := []string{"s3:GetObject", "s3:DeleteObject"}
+	resources := []string{"arn:aws:s3:::reports/a"}
+	reqCtx := map[string][]string{"aws:SourceVpc": {"vpc-123"}}
+
+	tests := []struct {
+		name          string
+		results       []types.SimulationResult
+		err           error
+		expectedError string
+	}{
+		{
+			name: "decisions of the simulator are kept per action and resource",
+			results: []types.SimulationResult{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a", Decision: "allowed", MatchedPolicies: []string{"reports"}},
+				{Action: "s3:DeleteObject", Resource: "arn:aws:s3:::reports/a", Decision: "implicitly denied", MissingContext: []string{"aws:SourceIp"}},
+			},
+		},
+		{
+			name:          "simulator failure",
+			err:           assert.AnError,
+			expectedError: "failed to simulate policies of role api-role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aws := &MockAWSClient{}
+			aws.On("SimulatePrincipalPolicy", mock.Anything, "api-role", actions, resources, reqCtx).Return(tt.results, tt.err)
+
+			perms := []types.PodPermissions{{PodName: "api", IAMRole: "api-role"}}
+			err := New(&MockK8sClient{}, aws).simulate(context.Background(), perms, actions, resources, reqCtx)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.results, perms[0].Simulation)
+			aws.AssertExpectations(t)
+		})
+	}
+}
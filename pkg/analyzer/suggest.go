@@ -0,0 +1,25 @@
+package analyzer
+
+import (
+	"context"
+
+	"github.com/berkguzel/pperm/pkg/cloudtrail"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// recordedCalls reads the CloudTrail logs in dir and collects the calls the
+// role of each pod with an IAM role made
+func (a *Analyzer) recordedCalls(perms []types.PodPermissions, dir string) error {
+	events, err := cloudtrail.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	return forEachRole(context.Background(), perms, byRole,
+		func(_ context.Context, role string) ([]types.APICall, error) {
+			return cloudtrail.Calls(events, role), nil
+		},
+		func(perm *types.PodPermissions, calls []types.APICall) {
+			perm.Calls = calls
+		})
+}
@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordedCalls(t *testing.T) {
+	dir := t.TempDir()
+	log := `{"Records": [{
+		"eventTime": "2024-05-01T10:00:00Z",
+		"eventSource": "s3.amazonaws.com",
+		"eventName": "HeadObject",
+		"userIdentity": {"type": "AssumedRole", "sessionContext": {"sessionIssuer": {"arn": "arn:aws:iam::123456789012:role/api"}}},
+		"resources": [{"ARN": "arn:aws:s3:::reports/a.csv", "type": "AWS::S3::Object"}]
+	}]}`
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "log.json"), []byte(log), 0o600))
+
+	tests := []struct {
+		name          string
+		role          string
+		dir           string
+		expected      []string
+		expectedError string
+	}{
+		{
+			name:     "calls authorized as the IAM action",
+			role:     "arn:aws:iam::123456789012:role/api",
+			dir:      dir,
+			expected: []string{"s3:GetObject on arn:aws:s3:::reports/a.csv"},
+		},
+		{
+			name:     "role named without its ARN",
+			role:     "api",
+			dir:      dir,
+			expected: []string{"s3:GetObject on arn:aws:s3:::reports/a.csv"},
+		},
+		{
+			name: "role that made no calls",
+			role: "arn:aws:iam::123456789012:role/worker",
+			dir:  dir,
+		},
+		{
+			name:          "missing log directory",
+			role:          "api",
+			dir:           filepath.Join(dir, "missing"),
+			expectedError: "failed to read CloudTrail logs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perms := []types.PodPermissions{{PodName: "api", IAMRole: tt.role}}
+			err := New(&MockK8sClient{}, &MockAWSClient{}).recordedCalls(perms, tt.dir)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+
+			var calls []string
+			for _, call := range perms[0].Calls {
+				calls = append(calls, call.Action+" on "+call.Resource)
+			}
+			assert.Equal(t, tt.expected, calls)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// lastAccessed fetches the IAM last-accessed data of the role of each pod
+// with an IAM role. Pods sharing a role share the report.
+func (a *Analyzer) lastAccessed(ctx context.Context, perms []types.PodPermissions) error {
+	return forEachRole(ctx, perms, byRole,
+		func(ctx context.Context, role string) ([]types.ServiceUsage, error) {
+			usage, err := a.awsClient.GetServiceLastAccessed(ctx, role)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get last accessed data of role %s: %v", role, err)
+			}
+			return usage, nil
+		},
+		func(perm *types.PodPermissions, usage []types.ServiceUsage) {
+			perm.Usage = usage
+		})
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLastAccessed(t *testing.T) {
+	tests := []struct {
+		name          string
+		usage         []types.ServiceUsage
+		err           error
+		expectedError string
+	}{
+		{
+			name: "report kept per service and action",
+			usage: []types.ServiceUsage{
+				{
+					Service:  "s3",
+					LastUsed: time.Date(2024, 5, 2, 10, 14, 0, 0, time.UTC),
+					Actions:  []types.ActionUsage{{Action: "s3:GetObject", LastUsed: time.Date(2024, 5, 2, 10, 14, 0, 0, time.UTC)}},
+				},
+				{Service: "sqs"},
+			},
+		},
+		{
+			name:          "report cannot be generated",
+			err:           assert.AnError,
+			expectedError: "failed to get last accessed data of role api-role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aws := &MockAWSClient{}
+			aws.On("GetServiceLastAccessed", mock.Anything, "api-role").Return(tt.usage, tt.err)
+
+			perms := []types.PodPermissions{{PodName: "api", IAMRole: "api-role"}}
+			err := New(&MockK8sClient{}, aws).lastAccessed(context.Background(), perms)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.usage, perms[0].Usage)
+		})
+	}
+}
@@ -7,17 +7,42 @@ import (
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 )
 
 type IAMClient interface {
 	GetPolicy(ctx context.Context, params *iam.GetPolicyInput, optFns ...func(*iam.Options)) (*iam.GetPolicyOutput, error)
 	GetPolicyVersion(ctx context.Context, params *iam.GetPolicyVersionInput, optFns ...func(*iam.Options)) (*iam.GetPolicyVersionOutput, error)
+	ListPolicyVersions(ctx context.Context, params *iam.ListPolicyVersionsInput, optFns ...func(*iam.Options)) (*iam.ListPolicyVersionsOutput, error)
 	ListAttachedRolePolicies(ctx context.Context, params *iam.ListAttachedRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error)
+	ListRolePolicies(ctx context.Context, params *iam.ListRolePoliciesInput, optFns ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error)
+	GetRolePolicy(ctx context.Context, params *iam.GetRolePolicyInput, optFns ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error)
+	GetRole(ctx context.Context, params *iam.GetRoleInput, optFns ...func(*iam.Options)) (*iam.GetRoleOutput, error)
+	GetInstanceProfile(ctx context.Context, params *iam.GetInstanceProfileInput, optFns ...func(*iam.Options)) (*iam.GetInstanceProfileOutput, error)
+	SimulatePrincipalPolicy(ctx context.Context, params *iam.SimulatePrincipalPolicyInput, optFns ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error)
+	GenerateServiceLastAccessedDetails(ctx context.Context, params *iam.GenerateServiceLastAccessedDetailsInput, optFns ...func(*iam.Options)) (*iam.GenerateServiceLastAccessedDetailsOutput, error)
+	GetServiceLastAccessedDetails(ctx context.Context, params *iam.GetServiceLastAccessedDetailsInput, optFns ...func(*iam.Options)) (*iam.GetServiceLastAccessedDetailsOutput, error)
+}
+
+// EKSClient is the subset of the EKS API used to resolve EKS Pod Identity
+// associations
+type EKSClient interface {
+	ListPodIdentityAssociations(ctx context.Context, params *eks.ListPodIdentityAssociationsInput, optFns ...func(*eks.Options)) (*eks.ListPodIdentityAssociationsOutput, error)
+	DescribePodIdentityAssociation(ctx context.Context, params *eks.DescribePodIdentityAssociationInput, optFns ...func(*eks.Options)) (*eks.DescribePodIdentityAssociationOutput, error)
+}
+
+// EC2Client is the subset of the EC2 API used to find the instance profile
+// of a node
+type EC2Client interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
 }
 
 type Client struct {
 	iamClient IAMClient
+	eksClient EKSClient
+	ec2Client EC2Client
 }
 
 func NewClient() (*Client, error) {
@@ -58,6 +83,8 @@ func NewClient() (*Client, error) {
 
 	return &Client{
 		iamClient: iam.NewFromConfig(cfg),
+		eksClient: eks.NewFromConfig(cfg),
+		ec2Client: ec2.NewFromConfig(cfg),
 	}, nil
 }
 
@@ -73,28 +100,3 @@ func getRoleNameFromARN(arn string) string {
 	parts := strings.Split(arn, "/")
 	return parts[len(parts)-1]
 }
-
-func isHighRiskService(action string) bool {
-	highRiskServices := []string{
-		"iam:",
-		"kms:",
-		"secretsmanager:",
-		"lambda:",
-		"ec2:",
-		"rds:",
-		"dynamodb:",
-	}
-
-	for _, service := range highRiskServices {
-		if strings.HasPrefix(action, service) {
-			return true
-		}
-	}
-
-	// Also consider any action with full service access (*) as high risk
-	if strings.HasSuffix(action, ":*") {
-		return true
-	}
-
-	return false
-}
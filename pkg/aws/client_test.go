@@ -76,6 +76,8 @@ func TestNewClient(t *testing.T) {
 				assert.NoError(t, err)
 				assert.NotNil(t, client)
 				assert.NotNil(t, client.iamClient)
+				assert.NotNil(t, client.eksClient)
+				assert.NotNil(t, client.ec2Client)
 			}
 		})
 	}
@@ -0,0 +1,53 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+)
+
+// GetInstanceProfileRole returns the IAM role of the instance profile
+// attached to an EC2 instance, or nothing when the instance has no profile or
+// its profile no role. Pods without a role of their own fall back to these
+// credentials through the instance metadata service.
+func (c *Client) GetInstanceProfileRole(ctx context.Context, instanceID string) (string, error) {
+	result, err := c.ec2Client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{
+		InstanceIds: []string{instanceID},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe instance %s: %v", instanceID, err)
+	}
+
+	var profileArn string
+	for _, reservation := range result.Reservations {
+		for _, instance := range reservation.Instances {
+			if instance.IamInstanceProfile != nil {
+				profileArn = aws.ToString(instance.IamInstanceProfile.Arn)
+			}
+		}
+	}
+	if profileArn == "" {
+		return "", nil
+	}
+
+	// Instance profile ARNs may include a path, the name is the last segment
+	profileName := profileArn[strings.LastIndex(profileArn, "/")+1:]
+
+	profile, err := c.iamClient.GetInstanceProfile(ctx, &iam.GetInstanceProfileInput{
+		InstanceProfileName: aws.String(profileName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get instance profile %s: %v", profileName, err)
+	}
+
+	// An instance profile holds at most one role
+	if len(profile.InstanceProfile.Roles) == 0 {
+		return "", nil
+	}
+
+	return aws.ToString(profile.InstanceProfile.Roles[0].Arn), nil
+}
@@ -0,0 +1,112 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEC2Client mocks the EC2 client for testing
+type MockEC2Client struct {
+	mock.Mock
+}
+
+func (m *MockEC2Client) DescribeInstances(ctx context.Context, input *ec2.DescribeInstancesInput, opts ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*ec2.DescribeInstancesOutput), args.Error(1)
+}
+
+func TestGetInstanceProfileRole(t *testing.T) {
+	describeInput := &ec2.DescribeInstancesInput{InstanceIds: []string{"i-0123456789abcdef0"}}
+	instanceWithProfile := func(arn string) *ec2.DescribeInstancesOutput {
+		return &ec2.DescribeInstancesOutput{
+			Reservations: []ec2types.Reservation{{
+				Instances: []ec2types.Instance{{
+					IamInstanceProfile: &ec2types.IamInstanceProfile{Arn: aws.String(arn)},
+				}},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		setupMocks    func(*MockEC2Client, *MockIAMClient)
+		expected      string
+		expectedError string
+	}{
+		{
+			name: "instance profile with role",
+			setupMocks: func(ec2Client *MockEC2Client, iamClient *MockIAMClient) {
+				ec2Client.On("DescribeInstances", mock.Anything, describeInput).
+					Return(instanceWithProfile("arn:aws:iam::123456789012:instance-profile/eks/node-profile"), nil)
+				iamClient.On("GetInstanceProfile", mock.Anything, &iam.GetInstanceProfileInput{
+					InstanceProfileName: aws.String("node-profile"),
+				}).Return(&iam.GetInstanceProfileOutput{
+					InstanceProfile: &iamtypes.InstanceProfile{
+						Roles: []iamtypes.Role{{Arn: aws.String("arn:aws:iam::123456789012:role/node-role")}},
+					},
+				}, nil)
+			},
+			expected: "arn:aws:iam::123456789012:role/node-role",
+		},
+		{
+			name: "instance without profile",
+			setupMocks: func(ec2Client *MockEC2Client, iamClient *MockIAMClient) {
+				ec2Client.On("DescribeInstances", mock.Anything, describeInput).Return(&ec2.DescribeInstancesOutput{
+					Reservations: []ec2types.Reservation{{Instances: []ec2types.Instance{{}}}},
+				}, nil)
+			},
+			expected: "",
+		},
+		{
+			name: "instance profile without role",
+			setupMocks: func(ec2Client *MockEC2Client, iamClient *MockIAMClient) {
+				ec2Client.On("DescribeInstances", mock.Anything, describeInput).
+					Return(instanceWithProfile("arn:aws:iam::123456789012:instance-profile/empty"), nil)
+				iamClient.On("GetInstanceProfile", mock.Anything, &iam.GetInstanceProfileInput{
+					InstanceProfileName: aws.String("empty"),
+				}).Return(&iam.GetInstanceProfileOutput{
+					InstanceProfile: &iamtypes.InstanceProfile{},
+				}, nil)
+			},
+			expected: "",
+		},
+		{
+			name: "describe fails",
+			setupMocks: func(ec2Client *MockEC2Client, iamClient *MockIAMClient) {
+				ec2Client.On("DescribeInstances", mock.Anything, describeInput).
+					Return((*ec2.DescribeInstancesOutput)(nil), fmt.Errorf("access denied"))
+			},
+			expectedError: "failed to describe instance i-0123456789abcdef0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ec2Client := &MockEC2Client{}
+			iamClient := &MockIAMClient{}
+			tt.setupMocks(ec2Client, iamClient)
+			client := &Client{iamClient: iamClient, ec2Client: ec2Client}
+
+			role, err := client.GetInstanceProfileRole(context.Background(), "i-0123456789abcdef0")
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, role)
+			}
+
+			ec2Client.AssertExpectations(t)
+			iamClient.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+)
+
+// GetPodIdentityRole returns the IAM role granted to a service account
+// through an EKS Pod Identity association, or nothing when it has none. A
+// service account has at most one association per cluster.
+func (c *Client) GetPodIdentityRole(ctx context.Context, clusterName, namespace, saName string) (string, error) {
+	result, err := c.eksClient.ListPodIdentityAssociations(ctx, &eks.ListPodIdentityAssociationsInput{
+		ClusterName:    aws.String(clusterName),
+		Namespace:      aws.String(namespace),
+		ServiceAccount: aws.String(saName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list pod identity associations: %v", err)
+	}
+
+	if len(result.Associations) == 0 {
+		return "", nil
+	}
+
+	association, err := c.eksClient.DescribePodIdentityAssociation(ctx, &eks.DescribePodIdentityAssociationInput{
+		ClusterName:   aws.String(clusterName),
+		AssociationId: result.Associations[0].AssociationId,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe pod identity association: %v", err)
+	}
+
+	return aws.ToString(association.Association.RoleArn), nil
+}
@@ -0,0 +1,97 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eks"
+	ekstypes "github.com/aws/aws-sdk-go-v2/service/eks/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEKSClient mocks the EKS client for testing
+type MockEKSClient struct {
+	mock.Mock
+}
+
+func (m *MockEKSClient) ListPodIdentityAssociations(ctx context.Context, input *eks.ListPodIdentityAssociationsInput, opts ...func(*eks.Options)) (*eks.ListPodIdentityAssociationsOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*eks.ListPodIdentityAssociationsOutput), args.Error(1)
+}
+
+func (m *MockEKSClient) DescribePodIdentityAssociation(ctx context.Context, input *eks.DescribePodIdentityAssociationInput, opts ...func(*eks.Options)) (*eks.DescribePodIdentityAssociationOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*eks.DescribePodIdentityAssociationOutput), args.Error(1)
+}
+
+func TestGetPodIdentityRole(t *testing.T) {
+	listInput := &eks.ListPodIdentityAssociationsInput{
+		ClusterName:    aws.String("prod"),
+		Namespace:      aws.String("payments"),
+		ServiceAccount: aws.String("api-sa"),
+	}
+
+	tests := []struct {
+		name          string
+		setupMocks    func(*MockEKSClient)
+		expected      string
+		expectedError string
+	}{
+		{
+			name: "association found",
+			setupMocks: func(m *MockEKSClient) {
+				m.On("ListPodIdentityAssociations", mock.Anything, listInput).Return(&eks.ListPodIdentityAssociationsOutput{
+					Associations: []ekstypes.PodIdentityAssociationSummary{
+						{AssociationId: aws.String("a-123")},
+					},
+				}, nil)
+				m.On("DescribePodIdentityAssociation", mock.Anything, &eks.DescribePodIdentityAssociationInput{
+					ClusterName:   aws.String("prod"),
+					AssociationId: aws.String("a-123"),
+				}).Return(&eks.DescribePodIdentityAssociationOutput{
+					Association: &ekstypes.PodIdentityAssociation{
+						RoleArn: aws.String("arn:aws:iam::123456789012:role/api-role"),
+					},
+				}, nil)
+			},
+			expected: "arn:aws:iam::123456789012:role/api-role",
+		},
+		{
+			name: "no association",
+			setupMocks: func(m *MockEKSClient) {
+				m.On("ListPodIdentityAssociations", mock.Anything, listInput).Return(&eks.ListPodIdentityAssociationsOutput{}, nil)
+			},
+			expected: "",
+		},
+		{
+			name: "list fails",
+			setupMocks: func(m *MockEKSClient) {
+				m.On("ListPodIdentityAssociations", mock.Anything, listInput).
+					Return((*eks.ListPodIdentityAssociationsOutput)(nil), fmt.Errorf("access denied"))
+			},
+			expectedError: "failed to list pod identity associations",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockEKSClient{}
+			tt.setupMocks(mockClient)
+			client := &Client{eksClient: mockClient}
+
+			role, err := client.GetPodIdentityRole(context.Background(), "prod", "payments", "api-sa")
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, role)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
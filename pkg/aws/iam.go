@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,6 +16,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/berkguzel/pperm/pkg/evaluator"
 	"github.com/berkguzel/pperm/pkg/types"
 )
 
@@ -35,6 +39,10 @@ const (
 	apiOperationTimeout  = 5 * time.Second
 )
 
+// lastAccessedPollInterval is how long to wait between checks of a pending
+// last-accessed report
+var lastAccessedPollInterval = 2 * time.Second
+
 // Add at the top of the file after imports
 const cacheFileName = ".pperm_cache.json"
 
@@ -237,20 +245,22 @@ func (c *Client) GetRolePolicies(ctx context.Context, roleArn string) ([]types.P
 	roleName := getRoleNameFromARN(roleArn)
 	var policies []types.Policy
 
-	result, err := c.iamClient.ListAttachedRolePolicies(ctx, &iam.ListAttachedRolePoliciesInput{
-		RoleName: &roleName,
-	})
-
+	attached, err := c.listAttachedRolePolicies(ctx, roleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list attached role policies: %v", err)
 	}
 
+	inline, err := c.listRolePolicyNames(ctx, roleName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inline role policies: %v", err)
+	}
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	errorChan := make(chan error, len(result.AttachedPolicies))
+	errorChan := make(chan error, len(attached)+len(inline))
 
 	// DISABLE CACHE - Always fetch fresh data
-	for _, policy := range result.AttachedPolicies {
+	for _, policy := range attached {
 		wg.Add(1)
 		go func(p iamtypes.AttachedPolicy) {
 			defer wg.Done()
@@ -268,12 +278,34 @@ func (c *Client) GetRolePolicies(ctx context.Context, roleArn string) ([]types.P
 			policies = append(policies, types.Policy{
 				Name:        policyName,
 				Arn:         policyArn,
+				Type:        types.PolicyTypeManaged,
 				Permissions: perms,
 			})
 			mu.Unlock()
 		}(policy)
 	}
 
+	for _, policyName := range inline {
+		wg.Add(1)
+		go func(policyName string) {
+			defer wg.Done()
+
+			perms, err := c.getInlinePolicyPermissions(ctx, roleName, policyName)
+			if err != nil {
+				errorChan <- fmt.Errorf("failed to get inline policy %s: %v", policyName, err)
+				return
+			}
+
+			mu.Lock()
+			policies = append(policies, types.Policy{
+				Name:        policyName,
+				Type:        types.PolicyTypeInline,
+				Permissions: perms,
+			})
+			mu.Unlock()
+		}(policyName)
+	}
+
 	go func() {
 		wg.Wait()
 		close(errorChan)
@@ -291,6 +323,77 @@ func (c *Client) GetRolePolicies(ctx context.Context, roleArn string) ([]types.P
 	return policies, nil
 }
 
+// GetRolePermissionsBoundary returns the managed policy set as the role's
+// permissions boundary, or nil when the role has none
+func (c *Client) GetRolePermissionsBoundary(ctx context.Context, roleArn string) (*types.Policy, error) {
+	roleName := getRoleNameFromARN(roleArn)
+
+	roleCtx, cancel := context.WithTimeout(ctx, apiOperationTimeout)
+	defer cancel()
+
+	role, err := c.iamClient.GetRole(roleCtx, &iam.GetRoleInput{
+		RoleName: &roleName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role: %v", err)
+	}
+
+	if role.Role == nil || role.Role.PermissionsBoundary == nil {
+		return nil, nil
+	}
+
+	policyArn := aws.ToString(role.Role.PermissionsBoundary.PermissionsBoundaryArn)
+	perms, err := c.GetPolicyPermissions(ctx, policyArn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get permissions boundary %s: %v", policyArn, err)
+	}
+
+	return &types.Policy{
+		Name:        policyArn[strings.LastIndex(policyArn, "/")+1:],
+		Arn:         policyArn,
+		Type:        types.PolicyTypeBoundary,
+		Permissions: perms,
+	}, nil
+}
+
+// listAttachedRolePolicies returns every managed policy attached to the
+// role, following IsTruncated/Marker across pages
+func (c *Client) listAttachedRolePolicies(ctx context.Context, roleName string) ([]iamtypes.AttachedPolicy, error) {
+	var policies []iamtypes.AttachedPolicy
+
+	paginator := iam.NewListAttachedRolePoliciesPaginator(c.iamClient, &iam.ListAttachedRolePoliciesInput{
+		RoleName: &roleName,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, page.AttachedPolicies...)
+	}
+
+	return policies, nil
+}
+
+// listRolePolicyNames returns the names of every inline policy embedded in
+// the role, following IsTruncated/Marker across pages
+func (c *Client) listRolePolicyNames(ctx context.Context, roleName string) ([]string, error) {
+	var names []string
+
+	paginator := iam.NewListRolePoliciesPaginator(c.iamClient, &iam.ListRolePoliciesInput{
+		RoleName: &roleName,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, page.PolicyNames...)
+	}
+
+	return names, nil
+}
+
 // Fix the Metrics struct and methods
 type Metrics struct {
 	sync.RWMutex
@@ -388,19 +491,100 @@ func (c *Client) GetPolicyPermissions(ctx context.Context, policyArn string) ([]
 		return nil, fmt.Errorf("failed to get policy version: %v", err)
 	}
 
-	decodedDoc, err := url.QueryUnescape(*version.PolicyVersion.Document)
+	doc, err := parsePolicyDocument(aws.ToString(version.PolicyVersion.Document))
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode policy document: %v", err)
+		return nil, err
+	}
+
+	perms := formatPermissions(doc.Statement)
+
+	return perms, nil
+}
+
+// getInlinePolicyPermissions fetches a policy embedded in the role itself,
+// which unlike managed policies has no ARN or versions
+func (c *Client) getInlinePolicyPermissions(ctx context.Context, roleName, policyName string) ([]types.PermissionDisplay, error) {
+	policyCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	policy, err := c.iamClient.GetRolePolicy(policyCtx, &iam.GetRolePolicyInput{
+		RoleName:   &roleName,
+		PolicyName: &policyName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role policy: %v", err)
+	}
+
+	doc, err := parsePolicyDocument(aws.ToString(policy.PolicyDocument))
+	if err != nil {
+		return nil, err
+	}
+
+	return formatPermissions(doc.Statement), nil
+}
+
+// GetPolicyVersions returns the versions IAM keeps of a managed policy, at
+// most five, newest first and with the permissions of each
+func (c *Client) GetPolicyVersions(ctx context.Context, policyArn string) ([]types.PolicyVersion, error) {
+	paginator := iam.NewListPolicyVersionsPaginator(c.iamClient, &iam.ListPolicyVersionsInput{
+		PolicyArn: aws.String(policyArn),
+	})
+
+	var versions []types.PolicyVersion
+	for paginator.HasMorePages() {
+		pageCtx, cancel := context.WithTimeout(ctx, apiOperationTimeout)
+		page, err := paginator.NextPage(pageCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list policy versions: %v", err)
+		}
+
+		// Listed versions come without their document
+		for _, v := range page.Versions {
+			versionCtx, cancel := context.WithTimeout(ctx, apiOperationTimeout)
+			version, err := c.iamClient.GetPolicyVersion(versionCtx, &iam.GetPolicyVersionInput{
+				PolicyArn: aws.String(policyArn),
+				VersionId: v.VersionId,
+			})
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get policy version %s: %v", aws.ToString(v.VersionId), err)
+			}
+
+			doc, err := parsePolicyDocument(aws.ToString(version.PolicyVersion.Document))
+			if err != nil {
+				return nil, err
+			}
+
+			versions = append(versions, types.PolicyVersion{
+				VersionID:   aws.ToString(v.VersionId),
+				CreateDate:  aws.ToTime(v.CreateDate),
+				IsDefault:   v.IsDefaultVersion,
+				Permissions: formatPermissions(doc.Statement),
+			})
+		}
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].CreateDate.After(versions[j].CreateDate)
+	})
+
+	return versions, nil
+}
+
+// parsePolicyDocument decodes the URL-encoded policy JSON returned by IAM
+func parsePolicyDocument(document string) (PolicyDocument, error) {
+	decodedDoc, err := url.QueryUnescape(document)
+	if err != nil {
+		return PolicyDocument{}, fmt.Errorf("failed to decode policy document: %v", err)
 	}
 
 	var doc PolicyDocument
 	if err := json.Unmarshal([]byte(decodedDoc), &doc); err != nil {
-		return nil, fmt.Errorf("failed to parse policy document: %v", err)
+		return PolicyDocument{}, fmt.Errorf("failed to parse policy document: %v", err)
 	}
 
-	perms := formatPermissions(doc.Statement)
-
-	return perms, nil
+	return doc, nil
 }
 
 func formatPermissions(statements []Statement) []types.PermissionDisplay {
@@ -408,23 +592,31 @@ func formatPermissions(statements []Statement) []types.PermissionDisplay {
 
 	for _, stmt := range statements {
 		actions := getActions(stmt.Action)
+		notAction := stmt.NotAction != nil
+		if notAction {
+			actions = getActions(stmt.NotAction)
+		}
+
 		resources := getResources(stmt.Resource)
+		notResource := stmt.NotResource != nil
+		if notResource {
+			resources = getResources(stmt.NotResource)
+		}
 
 		// Explicit condition check
 		hasCondition := stmt.Condition != nil && len(stmt.Condition) > 0
+		conditions := getConditions(stmt.Condition)
 
 		for _, action := range actions {
 			for _, resource := range resources {
-				isBroad := strings.Contains(action, "*") || strings.Contains(resource, "*")
-				isHighRisk := isHighRiskService(action)
-
 				perm := types.PermissionDisplay{
 					Action:       action,
 					Resource:     resource,
 					Effect:       stmt.Effect,
-					IsBroad:      isBroad,
-					IsHighRisk:   isHighRisk,
 					HasCondition: hasCondition,
+					Conditions:   conditions,
+					NotAction:    notAction,
+					NotResource:  notResource,
 				}
 
 				permissions = append(permissions, perm)
@@ -434,3 +626,243 @@ func formatPermissions(statements []Statement) []types.PermissionDisplay {
 
 	return permissions
 }
+
+// SimulatePrincipalPolicy asks the AWS IAM policy simulator whether the role
+// may perform each of the actions on each of the resources, taking all of
+// its policies and its permissions boundary into account. reqCtx supplies the
+// values of condition keys. Decisions use the evaluator's wording so they can
+// be compared with local evaluation.
+func (c *Client) SimulatePrincipalPolicy(ctx context.Context, roleArn string, actions, resources []string, reqCtx map[string][]string) ([]types.SimulationResult, error) {
+	roleArn, err := c.GetRoleARN(ctx, roleArn)
+	if err != nil {
+		return nil, err
+	}
+
+	paginator := iam.NewSimulatePrincipalPolicyPaginator(c.iamClient, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(roleArn),
+		ActionNames:     actions,
+		ResourceArns:    resources,
+		ContextEntries:  contextEntries(reqCtx),
+	})
+
+	var results []types.SimulationResult
+	for paginator.HasMorePages() {
+		pageCtx, cancel := context.WithTimeout(ctx, apiOperationTimeout)
+		page, err := paginator.NextPage(pageCtx)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to simulate policies: %v", err)
+		}
+
+		for _, r := range page.EvaluationResults {
+			action := aws.ToString(r.EvalActionName)
+
+			// Results for several resources are broken down per resource
+			if len(r.ResourceSpecificResults) > 0 {
+				for _, rr := range r.ResourceSpecificResults {
+					results = append(results, types.SimulationResult{
+						Action:          action,
+						Resource:        aws.ToString(rr.EvalResourceName),
+						Decision:        simulatorDecision(rr.EvalResourceDecision),
+						MatchedPolicies: matchedPolicies(rr.MatchedStatements),
+						MissingContext:  rr.MissingContextValues,
+					})
+				}
+				continue
+			}
+
+			results = append(results, types.SimulationResult{
+				Action:          action,
+				Resource:        aws.ToString(r.EvalResourceName),
+				Decision:        simulatorDecision(r.EvalDecision),
+				MatchedPolicies: matchedPolicies(r.MatchedStatements),
+				MissingContext:  r.MissingContextValues,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// GetRoleARN returns the ARN of a role. kube2iam and kiam annotations may name
+// the role without its ARN, which is then looked up.
+func (c *Client) GetRoleARN(ctx context.Context, role string) (string, error) {
+	if strings.HasPrefix(role, "arn:") {
+		return role, nil
+	}
+
+	roleCtx, cancel := context.WithTimeout(ctx, apiOperationTimeout)
+	defer cancel()
+
+	out, err := c.iamClient.GetRole(roleCtx, &iam.GetRoleInput{
+		RoleName: aws.String(role),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get role: %v", err)
+	}
+	if out.Role == nil {
+		return "", fmt.Errorf("role %s not found", role)
+	}
+	return aws.ToString(out.Role.Arn), nil
+}
+
+// GetServiceLastAccessed reports when the role last used each service its
+// policies allow, and each action of the services IAM tracks actions of. It
+// starts an IAM last-accessed report for the role and waits for it to
+// complete.
+func (c *Client) GetServiceLastAccessed(ctx context.Context, roleArn string) ([]types.ServiceUsage, error) {
+	roleArn, err := c.GetRoleARN(ctx, roleArn)
+	if err != nil {
+		return nil, err
+	}
+
+	generateCtx, cancel := context.WithTimeout(ctx, apiOperationTimeout)
+	job, err := c.iamClient.GenerateServiceLastAccessedDetails(generateCtx, &iam.GenerateServiceLastAccessedDetailsInput{
+		Arn:         aws.String(roleArn),
+		Granularity: iamtypes.AccessAdvisorUsageGranularityTypeActionLevel,
+	})
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate last accessed report: %v", err)
+	}
+
+	var usage []types.ServiceUsage
+	var marker *string
+	for {
+		detailsCtx, cancel := context.WithTimeout(ctx, apiOperationTimeout)
+		details, err := c.iamClient.GetServiceLastAccessedDetails(detailsCtx, &iam.GetServiceLastAccessedDetailsInput{
+			JobId:  job.JobId,
+			Marker: marker,
+		})
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get last accessed report: %v", err)
+		}
+
+		switch details.JobStatus {
+		case iamtypes.JobStatusTypeFailed:
+			message := "unknown error"
+			if details.Error != nil {
+				message = aws.ToString(details.Error.Message)
+			}
+			return nil, fmt.Errorf("last accessed report failed: %s", message)
+		case iamtypes.JobStatusTypeInProgress:
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("last accessed report did not complete: %v", ctx.Err())
+			case <-time.After(lastAccessedPollInterval):
+			}
+			continue
+		}
+
+		for _, s := range details.ServicesLastAccessed {
+			usage = append(usage, serviceUsage(s))
+		}
+
+		if !details.IsTruncated {
+			return usage, nil
+		}
+		marker = details.Marker
+	}
+}
+
+// serviceUsage converts a service's last-accessed record. Tracked action
+// names come without their service prefix.
+func serviceUsage(s iamtypes.ServiceLastAccessed) types.ServiceUsage {
+	service := aws.ToString(s.ServiceNamespace)
+	usage := types.ServiceUsage{
+		Service:  service,
+		LastUsed: aws.ToTime(s.LastAuthenticated),
+	}
+	for _, a := range s.TrackedActionsLastAccessed {
+		name := aws.ToString(a.ActionName)
+		if !strings.Contains(name, ":") {
+			name = service + ":" + name
+		}
+		usage.Actions = append(usage.Actions, types.ActionUsage{
+			Action:   name,
+			LastUsed: aws.ToTime(a.LastAccessedTime),
+		})
+	}
+	return usage
+}
+
+// contextEntries converts condition key values to the policy simulator's
+// context entries, sorted by key. The simulator needs the type of each key,
+// which is guessed from its values.
+func contextEntries(reqCtx map[string][]string) []iamtypes.ContextEntry {
+	keys := make([]string, 0, len(reqCtx))
+	for key := range reqCtx {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entries []iamtypes.ContextEntry
+	for _, key := range keys {
+		values := reqCtx[key]
+		entries = append(entries, iamtypes.ContextEntry{
+			ContextKeyName:   aws.String(key),
+			ContextKeyType:   contextKeyType(values),
+			ContextKeyValues: values,
+		})
+	}
+	return entries
+}
+
+// contextKeyType guesses the simulator type of a condition key from its
+// values: IP addresses, booleans and numbers are recognized, anything else
+// is a string. Keys with several values are lists.
+func contextKeyType(values []string) iamtypes.ContextKeyTypeEnum {
+	is := func(parse func(string) bool) bool {
+		for _, v := range values {
+			if !parse(v) {
+				return false
+			}
+		}
+		return len(values) > 0
+	}
+
+	var keyType iamtypes.ContextKeyTypeEnum
+	switch {
+	case is(func(v string) bool { return net.ParseIP(v) != nil }):
+		keyType = iamtypes.ContextKeyTypeEnumIp
+	case is(func(v string) bool { return v == "true" || v == "false" }):
+		keyType = iamtypes.ContextKeyTypeEnumBoolean
+	case is(func(v string) bool { _, err := strconv.ParseFloat(v, 64); return err == nil }):
+		keyType = iamtypes.ContextKeyTypeEnumNumeric
+	default:
+		keyType = iamtypes.ContextKeyTypeEnumString
+	}
+
+	if len(values) > 1 {
+		keyType += "List"
+	}
+	return keyType
+}
+
+// simulatorDecision translates a policy simulator decision to the
+// evaluator's
+func simulatorDecision(decision iamtypes.PolicyEvaluationDecisionType) string {
+	switch decision {
+	case iamtypes.PolicyEvaluationDecisionTypeAllowed:
+		return evaluator.DecisionAllowed
+	case iamtypes.PolicyEvaluationDecisionTypeExplicitDeny:
+		return evaluator.DecisionExplicitDeny
+	default:
+		return evaluator.DecisionImplicitDeny
+	}
+}
+
+// matchedPolicies names the policies of the matched statements once each
+func matchedPolicies(statements []iamtypes.Statement) []string {
+	var policies []string
+	seen := make(map[string]bool)
+	for _, s := range statements {
+		id := aws.ToString(s.SourcePolicyId)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			policies = append(policies, id)
+		}
+	}
+	return policies
+}
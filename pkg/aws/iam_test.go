@@ -28,11 +28,51 @@ func (m *MockIAMClient) GetPolicyVersion(ctx context.Context, input *iam.GetPoli
 	return args.Get(0).(*iam.GetPolicyVersionOutput), args.Error(1)
 }
 
+func (m *MockIAMClient) ListPolicyVersions(ctx context.Context, input *iam.ListPolicyVersionsInput, opts ...func(*iam.Options)) (*iam.ListPolicyVersionsOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*iam.ListPolicyVersionsOutput), args.Error(1)
+}
+
+func (m *MockIAMClient) GenerateServiceLastAccessedDetails(ctx context.Context, input *iam.GenerateServiceLastAccessedDetailsInput, opts ...func(*iam.Options)) (*iam.GenerateServiceLastAccessedDetailsOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*iam.GenerateServiceLastAccessedDetailsOutput), args.Error(1)
+}
+
+func (m *MockIAMClient) GetServiceLastAccessedDetails(ctx context.Context, input *iam.GetServiceLastAccessedDetailsInput, opts ...func(*iam.Options)) (*iam.GetServiceLastAccessedDetailsOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*iam.GetServiceLastAccessedDetailsOutput), args.Error(1)
+}
+
 func (m *MockIAMClient) ListAttachedRolePolicies(ctx context.Context, input *iam.ListAttachedRolePoliciesInput, opts ...func(*iam.Options)) (*iam.ListAttachedRolePoliciesOutput, error) {
 	args := m.Called(ctx, input)
 	return args.Get(0).(*iam.ListAttachedRolePoliciesOutput), args.Error(1)
 }
 
+func (m *MockIAMClient) ListRolePolicies(ctx context.Context, input *iam.ListRolePoliciesInput, opts ...func(*iam.Options)) (*iam.ListRolePoliciesOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*iam.ListRolePoliciesOutput), args.Error(1)
+}
+
+func (m *MockIAMClient) GetRolePolicy(ctx context.Context, input *iam.GetRolePolicyInput, opts ...func(*iam.Options)) (*iam.GetRolePolicyOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*iam.GetRolePolicyOutput), args.Error(1)
+}
+
+func (m *MockIAMClient) GetInstanceProfile(ctx context.Context, input *iam.GetInstanceProfileInput, opts ...func(*iam.Options)) (*iam.GetInstanceProfileOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*iam.GetInstanceProfileOutput), args.Error(1)
+}
+
+func (m *MockIAMClient) GetRole(ctx context.Context, input *iam.GetRoleInput, opts ...func(*iam.Options)) (*iam.GetRoleOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*iam.GetRoleOutput), args.Error(1)
+}
+
+func (m *MockIAMClient) SimulatePrincipalPolicy(ctx context.Context, input *iam.SimulatePrincipalPolicyInput, opts ...func(*iam.Options)) (*iam.SimulatePrincipalPolicyOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(*iam.SimulatePrincipalPolicyOutput), args.Error(1)
+}
+
 func TestGetRolePolicies(t *testing.T) {
 	mockClient := &MockIAMClient{}
 	client := &Client{iamClient: mockClient}
@@ -65,10 +105,224 @@ func TestGetRolePolicies(t *testing.T) {
 		},
 	}, nil)
 
+	mockClient.On("ListRolePolicies", mock.Anything, &iam.ListRolePoliciesInput{
+		RoleName: aws.String("test-role"),
+	}).Return(&iam.ListRolePoliciesOutput{}, nil)
+
 	policies, err := client.GetRolePolicies(context.Background(), "test-role")
 	assert.NoError(t, err)
 	assert.Len(t, policies, 1)
 	assert.Equal(t, "test-policy", policies[0].Name)
+	assert.Equal(t, types.PolicyTypeManaged, policies[0].Type)
+}
+
+func TestGetRolePolicies_Inline(t *testing.T) {
+	mockClient := &MockIAMClient{}
+	client := &Client{iamClient: mockClient}
+
+	mockClient.On("ListAttachedRolePolicies", mock.Anything, &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String("test-role"),
+	}).Return(&iam.ListAttachedRolePoliciesOutput{}, nil)
+
+	mockClient.On("ListRolePolicies", mock.Anything, &iam.ListRolePoliciesInput{
+		RoleName: aws.String("test-role"),
+	}).Return(&iam.ListRolePoliciesOutput{
+		PolicyNames: []string{"inline-admin"},
+	}, nil)
+
+	// IAM returns inline policy documents URL-encoded
+	mockClient.On("GetRolePolicy", mock.Anything, &iam.GetRolePolicyInput{
+		RoleName:   aws.String("test-role"),
+		PolicyName: aws.String("inline-admin"),
+	}).Return(&iam.GetRolePolicyOutput{
+		PolicyName:     aws.String("inline-admin"),
+		PolicyDocument: aws.String(`%7B%22Version%22%3A%222012-10-17%22%2C%22Statement%22%3A%5B%7B%22Effect%22%3A%22Allow%22%2C%22Action%22%3A%22iam%3A%2A%22%2C%22Resource%22%3A%22%2A%22%7D%5D%7D`),
+	}, nil)
+
+	policies, err := client.GetRolePolicies(context.Background(), "arn:aws:iam::123456789012:role/test-role")
+	assert.NoError(t, err)
+	assert.Len(t, policies, 1)
+	assert.Equal(t, "inline-admin", policies[0].Name)
+	assert.Empty(t, policies[0].Arn)
+	assert.Equal(t, types.PolicyTypeInline, policies[0].Type)
+	assert.Len(t, policies[0].Permissions, 1)
+	assert.Equal(t, "iam:*", policies[0].Permissions[0].Action)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetRolePolicies_Paginated(t *testing.T) {
+	mockClient := &MockIAMClient{}
+	client := &Client{iamClient: mockClient}
+
+	// IAM caps each page, so a role with many attachments spans several calls
+	mockClient.On("ListAttachedRolePolicies", mock.Anything, &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String("test-role"),
+	}).Return(&iam.ListAttachedRolePoliciesOutput{
+		AttachedPolicies: []iamtypes.AttachedPolicy{
+			{
+				PolicyName: aws.String("policy-one"),
+				PolicyArn:  aws.String("arn:aws:iam::123456789012:policy/policy-one"),
+			},
+		},
+		IsTruncated: true,
+		Marker:      aws.String("page-2"),
+	}, nil)
+
+	mockClient.On("ListAttachedRolePolicies", mock.Anything, &iam.ListAttachedRolePoliciesInput{
+		RoleName: aws.String("test-role"),
+		Marker:   aws.String("page-2"),
+	}).Return(&iam.ListAttachedRolePoliciesOutput{
+		AttachedPolicies: []iamtypes.AttachedPolicy{
+			{
+				PolicyName: aws.String("policy-two"),
+				PolicyArn:  aws.String("arn:aws:iam::123456789012:policy/policy-two"),
+			},
+		},
+	}, nil)
+
+	mockClient.On("ListRolePolicies", mock.Anything, &iam.ListRolePoliciesInput{
+		RoleName: aws.String("test-role"),
+	}).Return(&iam.ListRolePoliciesOutput{
+		PolicyNames: []string{"inline-one"},
+		IsTruncated: true,
+		Marker:      aws.String("page-2"),
+	}, nil)
+
+	mockClient.On("ListRolePolicies", mock.Anything, &iam.ListRolePoliciesInput{
+		RoleName: aws.String("test-role"),
+		Marker:   aws.String("page-2"),
+	}).Return(&iam.ListRolePoliciesOutput{
+		PolicyNames: []string{"inline-two"},
+	}, nil)
+
+	document := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject"],"Resource":["*"]}]}`
+	for _, name := range []string{"policy-one", "policy-two"} {
+		policyArn := "arn:aws:iam::123456789012:policy/" + name
+		mockClient.On("GetPolicy", mock.Anything, &iam.GetPolicyInput{
+			PolicyArn: aws.String(policyArn),
+		}).Return(&iam.GetPolicyOutput{
+			Policy: &iamtypes.Policy{
+				DefaultVersionId: aws.String("v1"),
+			},
+		}, nil)
+		mockClient.On("GetPolicyVersion", mock.Anything, &iam.GetPolicyVersionInput{
+			PolicyArn: aws.String(policyArn),
+			VersionId: aws.String("v1"),
+		}).Return(&iam.GetPolicyVersionOutput{
+			PolicyVersion: &iamtypes.PolicyVersion{
+				Document: aws.String(document),
+			},
+		}, nil)
+	}
+
+	for _, name := range []string{"inline-one", "inline-two"} {
+		mockClient.On("GetRolePolicy", mock.Anything, &iam.GetRolePolicyInput{
+			RoleName:   aws.String("test-role"),
+			PolicyName: aws.String(name),
+		}).Return(&iam.GetRolePolicyOutput{
+			PolicyName:     aws.String(name),
+			PolicyDocument: aws.String(document),
+		}, nil)
+	}
+
+	policies, err := client.GetRolePolicies(context.Background(), "arn:aws:iam::123456789012:role/test-role")
+	assert.NoError(t, err)
+
+	var names []string
+	for _, policy := range policies {
+		names = append(names, policy.Name)
+	}
+	assert.ElementsMatch(t, []string{"policy-one", "policy-two", "inline-one", "inline-two"}, names)
+	mockClient.AssertExpectations(t)
+}
+
+func TestGetRolePermissionsBoundary(t *testing.T) {
+	boundaryArn := "arn:aws:iam::123456789012:policy/boundaries/s3-only"
+
+	tests := []struct {
+		name          string
+		setupMocks    func(*MockIAMClient)
+		expected      *types.Policy
+		expectedError string
+	}{
+		{
+			name: "boundary attached",
+			setupMocks: func(m *MockIAMClient) {
+				m.On("GetRole", mock.Anything, &iam.GetRoleInput{
+					RoleName: aws.String("test-role"),
+				}).Return(&iam.GetRoleOutput{
+					Role: &iamtypes.Role{
+						PermissionsBoundary: &iamtypes.AttachedPermissionsBoundary{
+							PermissionsBoundaryArn:  aws.String(boundaryArn),
+							PermissionsBoundaryType: iamtypes.PermissionsBoundaryAttachmentTypePolicy,
+						},
+					},
+				}, nil)
+				m.On("GetPolicy", mock.Anything, &iam.GetPolicyInput{
+					PolicyArn: aws.String(boundaryArn),
+				}).Return(&iam.GetPolicyOutput{
+					Policy: &iamtypes.Policy{
+						DefaultVersionId: aws.String("v2"),
+					},
+				}, nil)
+				m.On("GetPolicyVersion", mock.Anything, &iam.GetPolicyVersionInput{
+					PolicyArn: aws.String(boundaryArn),
+					VersionId: aws.String("v2"),
+				}).Return(&iam.GetPolicyVersionOutput{
+					PolicyVersion: &iamtypes.PolicyVersion{
+						Document: aws.String(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:*","Resource":"*"}]}`),
+					},
+				}, nil)
+			},
+			expected: &types.Policy{
+				Name: "s3-only",
+				Arn:  boundaryArn,
+				Type: types.PolicyTypeBoundary,
+				Permissions: []types.PermissionDisplay{
+					{Action: "s3:*", Resource: "*", Effect: "Allow"},
+				},
+			},
+		},
+		{
+			name: "no boundary",
+			setupMocks: func(m *MockIAMClient) {
+				m.On("GetRole", mock.Anything, &iam.GetRoleInput{
+					RoleName: aws.String("test-role"),
+				}).Return(&iam.GetRoleOutput{
+					Role: &iamtypes.Role{},
+				}, nil)
+			},
+			expected: nil,
+		},
+		{
+			name: "get role fails",
+			setupMocks: func(m *MockIAMClient) {
+				m.On("GetRole", mock.Anything, &iam.GetRoleInput{
+					RoleName: aws.String("test-role"),
+				}).Return((*iam.GetRoleOutput)(nil), assert.AnError)
+			},
+			expectedError: "failed to get role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &MockIAMClient{}
+			tt.setupMocks(mockClient)
+			client := &Client{iamClient: mockClient}
+
+			boundary, err := client.GetRolePermissionsBoundary(context.Background(), "arn:aws:iam::123456789012:role/test-role")
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, boundary)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
 }
 
 func TestGetPolicyPermissions(t *testing.T) {
@@ -101,6 +355,68 @@ func TestGetPolicyPermissions(t *testing.T) {
 	assert.Equal(t, "Allow", perms[0].Effect)
 }
 
+func TestFormatPermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		expected []types.PermissionDisplay
+	}{
+		{
+			name:     "action and resource",
+			document: `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"arn:aws:s3:::reports/*"}]}`,
+			expected: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+			},
+		},
+		{
+			name:     "allow with NotAction",
+			document: `{"Statement":[{"Effect":"Allow","NotAction":"iam:*","Resource":"*"}]}`,
+			expected: []types.PermissionDisplay{
+				{Action: "iam:*", Resource: "*", Effect: "Allow", NotAction: true},
+			},
+		},
+		{
+			name:     "allow with NotResource",
+			document: `{"Statement":[{"Effect":"Allow","Action":"s3:GetObject","NotResource":"arn:aws:s3:::payroll/data"}]}`,
+			expected: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::payroll/data", Effect: "Allow", NotResource: true},
+			},
+		},
+		{
+			name:     "conditions",
+			document: `{"Statement":[{"Effect":"Allow","Action":"s3:ListBucket","Resource":"arn:aws:s3:::reports","Condition":{"StringLike":{"s3:prefix":["home/","public/*"]},"StringEquals":{"aws:SourceVpce":"vpce-1a2b3c4d"},"Bool":{"aws:SecureTransport":true}}}]}`,
+			expected: []types.PermissionDisplay{
+				{
+					Action:       "s3:ListBucket",
+					Resource:     "arn:aws:s3:::reports",
+					Effect:       "Allow",
+					HasCondition: true,
+					Conditions: []types.Condition{
+						{Operator: "Bool", Key: "aws:SecureTransport", Values: []string{"true"}},
+						{Operator: "StringEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1a2b3c4d"}},
+						{Operator: "StringLike", Key: "s3:prefix", Values: []string{"home/", "public/*"}},
+					},
+				},
+			},
+		},
+		{
+			name:     "deny with NotAction",
+			document: `{"Statement":[{"Effect":"Deny","NotAction":["s3:GetObject"],"Resource":"arn:aws:s3:::reports"}]}`,
+			expected: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports", Effect: "Deny", NotAction: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parsePolicyDocument(tt.document)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, formatPermissions(doc.Statement))
+		})
+	}
+}
+
 func TestCache(t *testing.T) {
 	cache := &Cache{
 		items: make(map[string]cacheEntry),
@@ -195,38 +511,228 @@ func TestMaskSecret(t *testing.T) {
 	}
 }
 
-func TestIsHighRiskService(t *testing.T) {
-	tests := []struct {
-		name     string
-		action   string
-		expected bool
-	}{
-		{
-			name:     "iam service",
-			action:   "iam:CreateRole",
-			expected: true,
-		},
-		{
-			name:     "kms service",
-			action:   "kms:CreateKey",
-			expected: true,
+func TestGetPolicyVersions(t *testing.T) {
+	mockClient := &MockIAMClient{}
+	client := &Client{iamClient: mockClient}
+
+	policyArn := "arn:aws:iam::123456789012:policy/reports-writer"
+	created := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	mockClient.On("ListPolicyVersions", mock.Anything, &iam.ListPolicyVersionsInput{
+		PolicyArn: aws.String(policyArn),
+	}).Return(&iam.ListPolicyVersionsOutput{
+		Versions: []iamtypes.PolicyVersion{
+			{VersionId: aws.String("v1"), CreateDate: aws.Time(created)},
+			{VersionId: aws.String("v2"), CreateDate: aws.Time(created.AddDate(0, 2, 0)), IsDefaultVersion: true},
 		},
-		{
-			name:     "s3 service",
-			action:   "s3:GetObject",
-			expected: false,
+	}, nil)
+
+	documents := map[string]string{
+		"v1": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:PutObject","Resource":"arn:aws:s3:::reports/*"}]}`,
+		"v2": `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:PutObject","s3:DeleteObject"],"Resource":"arn:aws:s3:::reports/*"}]}`,
+	}
+	for id, document := range documents {
+		mockClient.On("GetPolicyVersion", mock.Anything, &iam.GetPolicyVersionInput{
+			PolicyArn: aws.String(policyArn),
+			VersionId: aws.String(id),
+		}).Return(&iam.GetPolicyVersionOutput{
+			PolicyVersion: &iamtypes.PolicyVersion{Document: aws.String(document)},
+		}, nil)
+	}
+
+	versions, err := client.GetPolicyVersions(context.Background(), policyArn)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+
+	// Newest first
+	assert.Equal(t, "v2", versions[0].VersionID)
+	assert.True(t, versions[0].IsDefault)
+	assert.Equal(t, created.AddDate(0, 2, 0), versions[0].CreateDate)
+	assert.Len(t, versions[0].Permissions, 2)
+	assert.Equal(t, "v1", versions[1].VersionID)
+	assert.False(t, versions[1].IsDefault)
+	assert.Equal(t, "s3:PutObject", versions[1].Permissions[0].Action)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSimulatePrincipalPolicy(t *testing.T) {
+	mockClient := &MockIAMClient{}
+	client := &Client{iamClient: mockClient}
+
+	mockClient.On("GetRole", mock.Anything, &iam.GetRoleInput{
+		RoleName: aws.String("test-role"),
+	}).Return(&iam.GetRoleOutput{
+		Role: &iamtypes.Role{Arn: aws.String("arn:aws:iam::123456789012:role/test-role")},
+	}, nil)
+
+	entries := []iamtypes.ContextEntry{
+		{ContextKeyName: aws.String("aws:SourceIp"), ContextKeyType: iamtypes.ContextKeyTypeEnumIp, ContextKeyValues: []string{"10.0.0.1"}},
+		{ContextKeyName: aws.String("aws:TagKeys"), ContextKeyType: iamtypes.ContextKeyTypeEnumStringList, ContextKeyValues: []string{"team", "env"}},
+	}
+
+	mockClient.On("SimulatePrincipalPolicy", mock.Anything, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String("arn:aws:iam::123456789012:role/test-role"),
+		ActionNames:     []string{"s3:GetObject", "s3:DeleteObject"},
+		ResourceArns:    []string{"arn:aws:s3:::reports/a", "arn:aws:s3:::payroll/b"},
+		ContextEntries:  entries,
+	}).Return(&iam.SimulatePrincipalPolicyOutput{
+		EvaluationResults: []iamtypes.EvaluationResult{
+			{
+				EvalActionName: aws.String("s3:GetObject"),
+				ResourceSpecificResults: []iamtypes.ResourceSpecificResult{
+					{
+						EvalResourceName:     aws.String("arn:aws:s3:::reports/a"),
+						EvalResourceDecision: iamtypes.PolicyEvaluationDecisionTypeAllowed,
+						MatchedStatements: []iamtypes.Statement{
+							{SourcePolicyId: aws.String("reports")},
+							{SourcePolicyId: aws.String("reports")},
+						},
+					},
+					{
+						EvalResourceName:     aws.String("arn:aws:s3:::payroll/b"),
+						EvalResourceDecision: iamtypes.PolicyEvaluationDecisionTypeImplicitDeny,
+						MissingContextValues: []string{"aws:SourceIp"},
+					},
+				},
+			},
 		},
-		{
-			name:     "wildcard action",
-			action:   "s3:*",
-			expected: true,
+		IsTruncated: true,
+		Marker:      aws.String("page-2"),
+	}, nil).Once()
+
+	mockClient.On("SimulatePrincipalPolicy", mock.Anything, &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String("arn:aws:iam::123456789012:role/test-role"),
+		ActionNames:     []string{"s3:GetObject", "s3:DeleteObject"},
+		ResourceArns:    []string{"arn:aws:s3:::reports/a", "arn:aws:s3:::payroll/b"},
+		ContextEntries:  entries,
+		Marker:          aws.String("page-2"),
+	}).Return(&iam.SimulatePrincipalPolicyOutput{
+		EvaluationResults: []iamtypes.EvaluationResult{
+			{
+				EvalActionName:   aws.String("s3:DeleteObject"),
+				EvalResourceName: aws.String("*"),
+				EvalDecision:     iamtypes.PolicyEvaluationDecisionTypeExplicitDeny,
+			},
 		},
+	}, nil).Once()
+
+	results, err := client.SimulatePrincipalPolicy(context.Background(), "test-role",
+		[]string{"s3:GetObject", "s3:DeleteObject"},
+		[]string{"arn:aws:s3:::reports/a", "arn:aws:s3:::payroll/b"},
+		map[string][]string{"aws:TagKeys": {"team", "env"}, "aws:SourceIp": {"10.0.0.1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []types.SimulationResult{
+		{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a", Decision: "allowed", MatchedPolicies: []string{"reports"}},
+		{Action: "s3:GetObject", Resource: "arn:aws:s3:::payroll/b", Decision: "implicitly denied", MissingContext: []string{"aws:SourceIp"}},
+		{Action: "s3:DeleteObject", Resource: "*", Decision: "explicitly denied"},
+	}, results)
+	mockClient.AssertExpectations(t)
+}
+
+func TestContextKeyType(t *testing.T) {
+	tests := []struct {
+		values   []string
+		expected iamtypes.ContextKeyTypeEnum
+	}{
+		{[]string{"vpc-123"}, iamtypes.ContextKeyTypeEnumString},
+		{[]string{"10.0.0.1"}, iamtypes.ContextKeyTypeEnumIp},
+		{[]string{"true"}, iamtypes.ContextKeyTypeEnumBoolean},
+		{[]string{"3600"}, iamtypes.ContextKeyTypeEnumNumeric},
+		{[]string{"10.0.0.1", "10.0.0.2"}, iamtypes.ContextKeyTypeEnumIpList},
+		{[]string{"team", "42"}, iamtypes.ContextKeyTypeEnumStringList},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := isHighRiskService(tt.action)
-			assert.Equal(t, tt.expected, result)
-		})
+		assert.Equal(t, tt.expected, contextKeyType(tt.values), "values %v", tt.values)
 	}
 }
+
+func TestSimulatorDecision(t *testing.T) {
+	tests := []struct {
+		decision iamtypes.PolicyEvaluationDecisionType
+		expected string
+	}{
+		{iamtypes.PolicyEvaluationDecisionTypeAllowed, "allowed"},
+		{iamtypes.PolicyEvaluationDecisionTypeExplicitDeny, "explicitly denied"},
+		{iamtypes.PolicyEvaluationDecisionTypeImplicitDeny, "implicitly denied"},
+		{"", "implicitly denied"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, simulatorDecision(tt.decision), "decision %q", tt.decision)
+	}
+}
+
+func TestGetServiceLastAccessed(t *testing.T) {
+	defer func(interval time.Duration) { lastAccessedPollInterval = interval }(lastAccessedPollInterval)
+	lastAccessedPollInterval = time.Millisecond
+
+	mockClient := &MockIAMClient{}
+	client := &Client{iamClient: mockClient}
+
+	roleArn := "arn:aws:iam::123456789012:role/api"
+	used := time.Date(2024, 5, 2, 10, 14, 0, 0, time.UTC)
+
+	mockClient.On("GenerateServiceLastAccessedDetails", mock.Anything, &iam.GenerateServiceLastAccessedDetailsInput{
+		Arn:         aws.String(roleArn),
+		Granularity: iamtypes.AccessAdvisorUsageGranularityTypeActionLevel,
+	}).Return(&iam.GenerateServiceLastAccessedDetailsOutput{JobId: aws.String("job-1")}, nil)
+
+	// The report is polled until it completes, then read page by page
+	mockClient.On("GetServiceLastAccessedDetails", mock.Anything, &iam.GetServiceLastAccessedDetailsInput{
+		JobId: aws.String("job-1"),
+	}).Return(&iam.GetServiceLastAccessedDetailsOutput{
+		JobStatus: iamtypes.JobStatusTypeInProgress,
+	}, nil).Once()
+	mockClient.On("GetServiceLastAccessedDetails", mock.Anything, &iam.GetServiceLastAccessedDetailsInput{
+		JobId: aws.String("job-1"),
+	}).Return(&iam.GetServiceLastAccessedDetailsOutput{
+		JobStatus: iamtypes.JobStatusTypeCompleted,
+		ServicesLastAccessed: []iamtypes.ServiceLastAccessed{
+			{
+				ServiceNamespace:  aws.String("iam"),
+				LastAuthenticated: aws.Time(used),
+				TrackedActionsLastAccessed: []iamtypes.TrackedActionLastAccessed{
+					{ActionName: aws.String("GetRole"), LastAccessedTime: aws.Time(used)},
+					{ActionName: aws.String("CreateRole")},
+				},
+			},
+		},
+		IsTruncated: true,
+		Marker:      aws.String("page-2"),
+	}, nil).Once()
+	mockClient.On("GetServiceLastAccessedDetails", mock.Anything, &iam.GetServiceLastAccessedDetailsInput{
+		JobId:  aws.String("job-1"),
+		Marker: aws.String("page-2"),
+	}).Return(&iam.GetServiceLastAccessedDetailsOutput{
+		JobStatus: iamtypes.JobStatusTypeCompleted,
+		ServicesLastAccessed: []iamtypes.ServiceLastAccessed{
+			{ServiceNamespace: aws.String("s3")},
+		},
+	}, nil).Once()
+
+	usage, err := client.GetServiceLastAccessed(context.Background(), roleArn)
+	assert.NoError(t, err)
+	assert.Equal(t, []types.ServiceUsage{
+		{
+			Service:  "iam",
+			LastUsed: used,
+			Actions: []types.ActionUsage{
+				{Action: "iam:GetRole", LastUsed: used},
+				{Action: "iam:CreateRole"},
+			},
+		},
+		{Service: "s3"},
+	}, usage)
+	mockClient.AssertExpectations(t)
+
+	failing := &MockIAMClient{}
+	client = &Client{iamClient: failing}
+	failing.On("GenerateServiceLastAccessedDetails", mock.Anything, mock.Anything).Return(&iam.GenerateServiceLastAccessedDetailsOutput{JobId: aws.String("job-2")}, nil)
+	failing.On("GetServiceLastAccessedDetails", mock.Anything, mock.Anything).Return(&iam.GetServiceLastAccessedDetailsOutput{
+		JobStatus: iamtypes.JobStatusTypeFailed,
+		Error:     &iamtypes.ErrorDetails{Message: aws.String("access denied")},
+	}, nil)
+	_, err = client.GetServiceLastAccessed(context.Background(), roleArn)
+	assert.EqualError(t, err, "last accessed report failed: access denied")
+}
@@ -1,13 +1,23 @@
 package aws
 
+import (
+	"fmt"
+	"sort"
+
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
 // Internal AWS types for policy parsing
 type Statement struct {
-	Effect    string                            `json:"Effect"`
-	Action    interface{}                       `json:"Action"`              // Can be string or []string
-	Resource  interface{}                       `json:"Resource"`            // Can be string or []string
-	Condition map[string]map[string]interface{} `json:"Condition,omitempty"` // For IAM policy conditions
-	Principal interface{}                       `json:"Principal,omitempty"`
-	Sid       string                            `json:"Sid,omitempty"`
+	Effect       string                            `json:"Effect"`
+	Action       interface{}                       `json:"Action"`                // Can be string or []string
+	NotAction    interface{}                       `json:"NotAction,omitempty"`   // Every action except these
+	Resource     interface{}                       `json:"Resource"`              // Can be string or []string
+	NotResource  interface{}                       `json:"NotResource,omitempty"` // Every resource except these
+	Condition    map[string]map[string]interface{} `json:"Condition,omitempty"`   // For IAM policy conditions
+	Principal    interface{}                       `json:"Principal,omitempty"`
+	NotPrincipal interface{}                       `json:"NotPrincipal,omitempty"`
+	Sid          string                            `json:"Sid,omitempty"`
 }
 
 type PolicyDocument struct {
@@ -57,3 +67,32 @@ func getResources(resource interface{}) []string {
 	}
 	return nil
 }
+
+// getConditions flattens a Condition block into one test per operator and
+// key, in a stable order. Values may be strings, booleans or numbers, alone
+// or in a list.
+func getConditions(block map[string]map[string]interface{}) []types.Condition {
+	var conditions []types.Condition
+	for operator, keys := range block {
+		for key, value := range keys {
+			var values []string
+			switch v := value.(type) {
+			case []interface{}:
+				for _, item := range v {
+					values = append(values, fmt.Sprint(item))
+				}
+			default:
+				values = []string{fmt.Sprint(v)}
+			}
+			conditions = append(conditions, types.Condition{Operator: operator, Key: key, Values: values})
+		}
+	}
+
+	sort.Slice(conditions, func(i, j int) bool {
+		if conditions[i].Key != conditions[j].Key {
+			return conditions[i].Key < conditions[j].Key
+		}
+		return conditions[i].Operator < conditions[j].Operator
+	})
+	return conditions
+}
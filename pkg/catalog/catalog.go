@@ -0,0 +1,161 @@
+// Package catalog knows the concrete actions of AWS services and their
+// access levels. The data is generated offline from the AWS service
+// reference and embedded in the binary, so expanding a wildcard such as
+// s3:Get* needs no API calls.
+package catalog
+
+//go:generate go run generate.go
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/berkguzel/pperm/pkg/evaluator"
+)
+
+// Access levels AWS assigns to every action
+const (
+	AccessList        = "List"
+	AccessRead        = "Read"
+	AccessWrite       = "Write"
+	AccessPermissions = "Permissions management"
+	AccessTagging     = "Tagging"
+)
+
+// AccessLevels lists the access levels from least to most privileged
+var AccessLevels = []string{AccessList, AccessRead, AccessTagging, AccessWrite, AccessPermissions}
+
+//go:embed catalog.json
+var catalogJSON []byte
+
+// Catalog maps the actions of each known service to their access level
+type Catalog struct {
+	// services maps a lowercase service prefix to its actions
+	services map[string][]Action
+}
+
+// Action is a concrete action of a service
+type Action struct {
+	Name        string
+	AccessLevel string
+}
+
+var (
+	defaultCatalog *Catalog
+	defaultOnce    sync.Once
+)
+
+// Default returns the catalog embedded in the binary
+func Default() *Catalog {
+	defaultOnce.Do(func() {
+		c, err := Parse(catalogJSON)
+		if err != nil {
+			panic(fmt.Sprintf("embedded action catalog: %v", err))
+		}
+		defaultCatalog = c
+	})
+	return defaultCatalog
+}
+
+// Parse reads a catalog in the embedded format: service prefix to access
+// level to action names
+func Parse(data []byte) (*Catalog, error) {
+	var raw map[string]map[string][]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse action catalog: %v", err)
+	}
+
+	c := &Catalog{services: make(map[string][]Action, len(raw))}
+	for service, levels := range raw {
+		var actions []Action
+		for level, names := range levels {
+			for _, name := range names {
+				actions = append(actions, Action{Name: service + ":" + name, AccessLevel: level})
+			}
+		}
+		sort.Slice(actions, func(i, j int) bool {
+			return actions[i].Name < actions[j].Name
+		})
+		c.services[strings.ToLower(service)] = actions
+	}
+
+	return c, nil
+}
+
+// Services returns the service prefixes in the catalog
+func (c *Catalog) Services() []string {
+	services := make([]string, 0, len(c.services))
+	for service := range c.services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Expand returns the concrete actions an IAM action pattern matches. The
+// result is only complete when known is true; a pattern naming a service
+// missing from the catalog, or a bare * spanning services the catalog does
+// not cover, reports known as false.
+func (c *Catalog) Expand(pattern string) (actions []Action, known bool) {
+	service, _, found := strings.Cut(pattern, ":")
+	if !found {
+		// A bare * or a malformed pattern may match actions of any service
+		for _, s := range c.Services() {
+			actions = append(actions, c.match(s, pattern)...)
+		}
+		return actions, false
+	}
+
+	service = strings.ToLower(service)
+	if strings.ContainsAny(service, "*?") {
+		for _, s := range c.Services() {
+			if evaluator.MatchAction(service, s) {
+				actions = append(actions, c.match(s, pattern)...)
+			}
+		}
+		return actions, false
+	}
+
+	if _, ok := c.services[service]; !ok {
+		return nil, false
+	}
+	return c.match(service, pattern), true
+}
+
+// Lookup returns a concrete action by name
+func (c *Catalog) Lookup(action string) (Action, bool) {
+	service, _, _ := strings.Cut(action, ":")
+	for _, a := range c.services[strings.ToLower(service)] {
+		if strings.EqualFold(a.Name, action) {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+// IsBroad reports whether an action pattern grants more than one action. A
+// wildcard the catalog expands to a single action, such as sqs:PurgeQ*, is as
+// narrow as naming it; one on a service the catalog does not know is assumed
+// broad.
+func (c *Catalog) IsBroad(pattern string) bool {
+	if !strings.ContainsAny(pattern, "*?") {
+		return false
+	}
+	actions, known := c.Expand(pattern)
+	return !known || len(actions) > 1
+}
+
+// match returns the actions of a service matching an action pattern
+func (c *Catalog) match(service, pattern string) []Action {
+	var matched []Action
+	for _, a := range c.services[service] {
+		if evaluator.MatchAction(pattern, a.Name) {
+			matched = append(matched, a)
+		}
+	}
+	return matched
+}
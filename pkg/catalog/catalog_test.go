@@ -0,0 +1,130 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCatalog = `{
+  "s3": {
+    "List": ["ListBucket"],
+    "Read": ["GetObject", "GetObjectAcl", "GetBucketPolicy"],
+    "Write": ["PutObject", "DeleteObject"],
+    "Permissions management": ["PutBucketPolicy"],
+    "Tagging": ["PutObjectTagging"]
+  },
+  "sqs": {
+    "Write": ["SendMessage"]
+  }
+}`
+
+func TestExpand(t *testing.T) {
+	c, err := Parse([]byte(testCatalog))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+		known   bool
+	}{
+		{
+			name:    "service wildcard",
+			pattern: "s3:Get*",
+			want:    []string{"s3:GetBucketPolicy", "s3:GetObject", "s3:GetObjectAcl"},
+			known:   true,
+		},
+		{
+			name:    "case-insensitive",
+			pattern: "S3:getobject*",
+			want:    []string{"s3:GetObject", "s3:GetObjectAcl"},
+			known:   true,
+		},
+		{
+			name:    "whole service",
+			pattern: "sqs:*",
+			want:    []string{"sqs:SendMessage"},
+			known:   true,
+		},
+		{
+			name:    "no match in known service",
+			pattern: "s3:Describe*",
+			want:    nil,
+			known:   true,
+		},
+		{
+			name:    "unknown service",
+			pattern: "ec2:Describe*",
+			want:    nil,
+			known:   false,
+		},
+		{
+			name:    "wildcard across services",
+			pattern: "*:Put*",
+			want:    []string{"s3:PutBucketPolicy", "s3:PutObject", "s3:PutObjectTagging"},
+			known:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions, known := c.Expand(tt.pattern)
+			var names []string
+			for _, a := range actions {
+				names = append(names, a.Name)
+			}
+			assert.Equal(t, tt.want, names)
+			assert.Equal(t, tt.known, known)
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	c, err := Parse([]byte(testCatalog))
+	assert.NoError(t, err)
+
+	a, ok := c.Lookup("s3:putbucketpolicy")
+	assert.True(t, ok)
+	assert.Equal(t, Action{Name: "s3:PutBucketPolicy", AccessLevel: AccessPermissions}, a)
+
+	_, ok = c.Lookup("s3:CreateJob")
+	assert.False(t, ok)
+}
+
+func TestIsBroad(t *testing.T) {
+	c, err := Parse([]byte(testCatalog))
+	assert.NoError(t, err)
+
+	assert.False(t, c.IsBroad("s3:GetObject"))
+	assert.True(t, c.IsBroad("s3:Get*"))
+	// Expands to sqs:SendMessage alone
+	assert.False(t, c.IsBroad("sqs:Send*"))
+	assert.True(t, c.IsBroad("ec2:Describe*"))
+	assert.True(t, c.IsBroad("*"))
+}
+
+func TestDefault(t *testing.T) {
+	c := Default()
+	assert.Contains(t, c.Services(), "s3")
+	assert.Contains(t, c.Services(), "iam")
+
+	// Read-only wildcards of the AWS managed read-only policies expand to
+	// read-only actions
+	actions, known := c.Expand("ec2:Describe*")
+	assert.True(t, known)
+	assert.Greater(t, len(actions), 100)
+	for _, a := range actions {
+		assert.Contains(t, []string{AccessList, AccessRead}, a.AccessLevel, a.Name)
+	}
+
+	// Every action sits at one of the known access levels
+	for _, service := range c.Services() {
+		actions, known := c.Expand(service + ":*")
+		assert.True(t, known)
+		assert.NotEmpty(t, actions)
+		for _, a := range actions {
+			assert.Contains(t, AccessLevels, a.AccessLevel, a.Name)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+//go:build ignore
+
+// generate rebuilds catalog.json from the AWS service reference. Run it with
+// go generate ./pkg/catalog, optionally passing service prefixes to restrict
+// the catalog to them:
+//
+//	go run generate.go s3 iam sts
+//
+// Without arguments the services already in catalog.json are refreshed; with
+// -all every service in the reference is included.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+const serviceListURL = "https://servicereference.us-east-1.amazonaws.com/v1/service-list.json"
+
+// serviceEntry is an entry of the service reference's service list
+type serviceEntry struct {
+	Service string `json:"service"`
+	URL     string `json:"url"`
+}
+
+// serviceReference is the part of a service's reference document we use
+type serviceReference struct {
+	Name    string `json:"Name"`
+	Actions []struct {
+		Name        string `json:"Name"`
+		Annotations struct {
+			Properties struct {
+				IsList                 bool `json:"IsList"`
+				IsPermissionManagement bool `json:"IsPermissionManagement"`
+				IsTaggingOnly          bool `json:"IsTaggingOnly"`
+				IsWrite                bool `json:"IsWrite"`
+			} `json:"Properties"`
+		} `json:"Annotations"`
+	} `json:"Actions"`
+}
+
+var client = &http.Client{Timeout: 30 * time.Second}
+
+func main() {
+	all := flag.Bool("all", false, "include every service in the reference")
+	output := flag.String("o", "catalog.json", "output file")
+	flag.Parse()
+
+	wanted, err := wantedServices(*all, *output, flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var entries []serviceEntry
+	if err := fetchJSON(serviceListURL, &entries); err != nil {
+		log.Fatalf("failed to fetch service list: %v", err)
+	}
+
+	catalog := make(map[string]map[string][]string)
+	for _, entry := range entries {
+		if wanted != nil && !wanted[entry.Service] {
+			continue
+		}
+
+		var ref serviceReference
+		if err := fetchJSON(entry.URL, &ref); err != nil {
+			log.Fatalf("failed to fetch %s reference: %v", entry.Service, err)
+		}
+
+		levels := make(map[string][]string)
+		for _, action := range ref.Actions {
+			level := accessLevel(action.Annotations.Properties.IsList,
+				action.Annotations.Properties.IsWrite,
+				action.Annotations.Properties.IsPermissionManagement,
+				action.Annotations.Properties.IsTaggingOnly)
+			levels[level] = append(levels[level], action.Name)
+		}
+		for _, names := range levels {
+			sort.Strings(names)
+		}
+		catalog[entry.Service] = levels
+	}
+
+	for service := range wanted {
+		if _, ok := catalog[service]; !ok {
+			log.Fatalf("service %q is not in the service reference", service)
+		}
+	}
+
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*output, append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// wantedServices returns the services to include, or nil for all of them
+func wantedServices(all bool, existing string, args []string) (map[string]bool, error) {
+	if all {
+		return nil, nil
+	}
+
+	wanted := make(map[string]bool)
+	for _, service := range args {
+		wanted[service] = true
+	}
+	if len(wanted) > 0 {
+		return wanted, nil
+	}
+
+	data, err := os.ReadFile(existing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", existing, err)
+	}
+	var current map[string]json.RawMessage
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", existing, err)
+	}
+	for service := range current {
+		wanted[service] = true
+	}
+	return wanted, nil
+}
+
+// accessLevel maps the reference's annotations to the access levels shown
+// in the IAM documentation. Actions with none of the flags set are Read.
+func accessLevel(isList, isWrite, isPermissionManagement, isTaggingOnly bool) string {
+	switch {
+	case isPermissionManagement:
+		return "Permissions management"
+	case isTaggingOnly:
+		return "Tagging"
+	case isWrite:
+		return "Write"
+	case isList:
+		return "List"
+	default:
+		return "Read"
+	}
+}
+
+func fetchJSON(url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
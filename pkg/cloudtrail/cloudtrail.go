@@ -0,0 +1,242 @@
+// Package cloudtrail reads CloudTrail log files exported to a local
+// directory and turns the calls a role made into a least-privilege policy.
+package cloudtrail
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// Event is a CloudTrail record, reduced to the fields needed to tell who
+// called which action on which resources
+type Event struct {
+	EventTime    time.Time    `json:"eventTime"`
+	EventSource  string       `json:"eventSource"` // e.g. s3.amazonaws.com
+	EventName    string       `json:"eventName"`   // e.g. GetObject
+	ErrorCode    string       `json:"errorCode"`
+	UserIdentity UserIdentity `json:"userIdentity"`
+	Resources    []Resource   `json:"resources"`
+}
+
+// UserIdentity is the caller of an event
+type UserIdentity struct {
+	Type           string         `json:"type"` // e.g. AssumedRole
+	SessionContext SessionContext `json:"sessionContext"`
+}
+
+type SessionContext struct {
+	SessionIssuer SessionIssuer `json:"sessionIssuer"`
+}
+
+// SessionIssuer is the role whose session made the call
+type SessionIssuer struct {
+	ARN string `json:"arn"` // e.g. arn:aws:iam::123456789012:role/api
+}
+
+// Resource is a resource an event acted on
+type Resource struct {
+	ARN  string `json:"ARN"`
+	Type string `json:"type"` // e.g. AWS::S3::Object
+}
+
+// logFile is the layout CloudTrail delivers records in
+type logFile struct {
+	Records []Event `json:"Records"`
+}
+
+// servicePrefixes maps the event sources whose name differs from the IAM
+// service prefix of their actions
+var servicePrefixes = map[string]string{
+	"monitoring": "cloudwatch",
+	"email":      "ses",
+	"api.ecr":    "ecr",
+}
+
+// eventActions maps the actions events are named after to the IAM actions
+// authorizing them, where the two differ. S3 authorizes listing objects and
+// reading their metadata with the bucket and object permissions, and
+// multipart uploads with s3:PutObject.
+var eventActions = map[string]string{
+	"s3:ListObjects":             "s3:ListBucket",
+	"s3:ListObjectsV2":           "s3:ListBucket",
+	"s3:ListObjectVersions":      "s3:ListBucketVersions",
+	"s3:HeadBucket":              "s3:ListBucket",
+	"s3:HeadObject":              "s3:GetObject",
+	"s3:ListBuckets":             "s3:ListAllMyBuckets",
+	"s3:ListParts":               "s3:ListMultipartUploadParts",
+	"s3:CreateMultipartUpload":   "s3:PutObject",
+	"s3:UploadPart":              "s3:PutObject",
+	"s3:CompleteMultipartUpload": "s3:PutObject",
+	"s3:DeleteObjects":           "s3:DeleteObject",
+	"lambda:Invoke":              "lambda:InvokeFunction",
+}
+
+// apiVersionSuffix matches the API version some services append to event
+// names, as in lambda's GetFunction20150331v2
+var apiVersionSuffix = regexp.MustCompile(`20\d{2}_?\d{2}_?\d{2}(v\d+)?$`)
+
+// ReadDir reads the records of every CloudTrail log file below dir. Files
+// are JSON as CloudTrail delivers them, optionally gzipped; other files are
+// skipped.
+func ReadDir(dir string) ([]Event, error) {
+	var events []Event
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !(strings.HasSuffix(path, ".json") || strings.HasSuffix(path, ".json.gz")) {
+			return nil
+		}
+
+		records, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		events = append(events, records...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CloudTrail logs: %v", err)
+	}
+
+	return events, nil
+}
+
+func readFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var log logFile
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return log.Records, nil
+}
+
+// Calls collects the calls sessions of a role made, by action and resource.
+// role is a role ARN, or a role name when the pod's credentials only name
+// it. Calls IAM denied did not need the permission they lacked and are left
+// out. Sessions are not filtered by name: with IRSA the SDK in the pod names
+// the session, at random unless AWS_ROLE_SESSION_NAME is set, so the name
+// does not identify the pod.
+func Calls(events []Event, role string) []types.APICall {
+	calls := make(map[[2]string]*types.APICall)
+	for _, e := range events {
+		if !calledBy(e, role) || accessDenied(e.ErrorCode) {
+			continue
+		}
+
+		action := eventAction(e)
+		for _, resource := range resources(e) {
+			key := [2]string{action, resource}
+			call, ok := calls[key]
+			if !ok {
+				call = &types.APICall{Action: action, Resource: resource, FirstSeen: e.EventTime, LastSeen: e.EventTime}
+				calls[key] = call
+			}
+			call.Count++
+			if e.EventTime.Before(call.FirstSeen) {
+				call.FirstSeen = e.EventTime
+			}
+			if e.EventTime.After(call.LastSeen) {
+				call.LastSeen = e.EventTime
+			}
+		}
+	}
+
+	result := make([]types.APICall, 0, len(calls))
+	for _, call := range calls {
+		result = append(result, *call)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Action != result[j].Action {
+			return result[i].Action < result[j].Action
+		}
+		return result[i].Resource < result[j].Resource
+	})
+	return result
+}
+
+// eventAction returns the IAM action an event was authorized as, e.g.
+// s3:GetObject for GetObject on s3.amazonaws.com
+func eventAction(e Event) string {
+	service := strings.TrimSuffix(e.EventSource, ".amazonaws.com")
+	if prefix, ok := servicePrefixes[service]; ok {
+		service = prefix
+	}
+	action := service + ":" + apiVersionSuffix.ReplaceAllString(e.EventName, "")
+	if authorizedAs, ok := eventActions[action]; ok {
+		return authorizedAs
+	}
+	return action
+}
+
+// calledBy reports whether a session of the role made the call. Role names
+// are unique within an account regardless of case.
+func calledBy(e Event, role string) bool {
+	issuer := e.UserIdentity.SessionContext.SessionIssuer.ARN
+	if e.UserIdentity.Type != "AssumedRole" || issuer == "" {
+		return false
+	}
+	if strings.HasPrefix(role, "arn:") {
+		return strings.EqualFold(issuer, role)
+	}
+	return strings.EqualFold(roleName(issuer), roleName(role))
+}
+
+// roleName strips the ARN and path of a role, leaving its name
+func roleName(role string) string {
+	return role[strings.LastIndex(role, "/")+1:]
+}
+
+func accessDenied(errorCode string) bool {
+	return strings.Contains(errorCode, "AccessDenied") || strings.Contains(errorCode, "UnauthorizedOperation")
+}
+
+// resources returns the ARNs an event acted on, or * when it names none.
+// Calls on an S3 object list its bucket as well, but are only authorized
+// against the object.
+func resources(e Event) []string {
+	object := false
+	for _, r := range e.Resources {
+		if r.Type == "AWS::S3::Object" {
+			object = true
+		}
+	}
+
+	var arns []string
+	for _, r := range e.Resources {
+		if r.ARN == "" || (object && r.Type == "AWS::S3::Bucket") {
+			continue
+		}
+		arns = append(arns, r.ARN)
+	}
+	if len(arns) == 0 {
+		return []string{"*"}
+	}
+	return arns
+}
@@ -0,0 +1,121 @@
+package cloudtrail
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testLog = `{"Records": [
+  {
+    "eventTime": "2024-05-01T10:00:00Z",
+    "eventSource": "s3.amazonaws.com",
+    "eventName": "GetObject",
+    "userIdentity": {
+      "type": "AssumedRole",
+      "sessionContext": {"sessionIssuer": {"arn": "arn:aws:iam::123456789012:role/api"}}
+    },
+    "resources": [
+      {"ARN": "arn:aws:s3:::reports/a.csv", "type": "AWS::S3::Object"},
+      {"ARN": "arn:aws:s3:::reports", "type": "AWS::S3::Bucket"}
+    ]
+  }
+]}`
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(testLog), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a log"), 0o600))
+
+	// CloudTrail delivers gzipped files, in nested directories
+	nested := filepath.Join(dir, "2024", "05")
+	assert.NoError(t, os.MkdirAll(nested, 0o700))
+	f, err := os.Create(filepath.Join(nested, "b.json.gz"))
+	assert.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte(testLog))
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, f.Close())
+
+	events, err := ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "GetObject", events[0].EventName)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/api", events[0].UserIdentity.SessionContext.SessionIssuer.ARN)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600))
+	_, err = ReadDir(dir)
+	assert.ErrorContains(t, err, "broken.json")
+
+	_, err = ReadDir(filepath.Join(dir, "missing"))
+	assert.ErrorContains(t, err, "failed to read CloudTrail logs")
+}
+
+func TestCalls(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 10, 0, 0, 0, time.UTC) }
+	event := func(d int, role, source, name string, resources ...Resource) Event {
+		e := Event{EventTime: day(d), EventSource: source, EventName: name, Resources: resources}
+		e.UserIdentity.Type = "AssumedRole"
+		e.UserIdentity.SessionContext.SessionIssuer.ARN = role
+		return e
+	}
+	api := "arn:aws:iam::123456789012:role/api"
+	object := Resource{ARN: "arn:aws:s3:::reports/a.csv", Type: "AWS::S3::Object"}
+	bucket := Resource{ARN: "arn:aws:s3:::reports", Type: "AWS::S3::Bucket"}
+
+	denied := event(4, api, "s3.amazonaws.com", "DeleteObject", object)
+	denied.ErrorCode = "AccessDenied"
+
+	events := []Event{
+		event(3, api, "s3.amazonaws.com", "GetObject", object, bucket),
+		event(1, api, "s3.amazonaws.com", "GetObject", object, bucket),
+		event(2, api, "s3.amazonaws.com", "ListObjects", bucket),
+		event(2, api, "monitoring.amazonaws.com", "PutMetricData"),
+		event(2, api, "lambda.amazonaws.com", "GetFunction20150331v2"),
+		event(2, "arn:aws:iam::123456789012:role/worker", "sqs.amazonaws.com", "SendMessage"),
+		denied,
+	}
+
+	expected := []types.APICall{
+		{Action: "cloudwatch:PutMetricData", Resource: "*", Count: 1, FirstSeen: day(2), LastSeen: day(2)},
+		{Action: "lambda:GetFunction", Resource: "*", Count: 1, FirstSeen: day(2), LastSeen: day(2)},
+		{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a.csv", Count: 2, FirstSeen: day(1), LastSeen: day(3)},
+		{Action: "s3:ListBucket", Resource: "arn:aws:s3:::reports", Count: 1, FirstSeen: day(2), LastSeen: day(2)},
+	}
+
+	assert.Equal(t, expected, Calls(events, api))
+
+	// Roles may be named without their ARN, as kube2iam annotations do
+	assert.Equal(t, expected, Calls(events, "api"))
+
+	assert.Empty(t, Calls(events, "arn:aws:iam::999999999999:role/api"))
+}
+
+func TestEventAction(t *testing.T) {
+	tests := []struct {
+		source   string
+		name     string
+		expected string
+	}{
+		{"s3.amazonaws.com", "GetObject", "s3:GetObject"},
+		{"s3.amazonaws.com", "ListObjects", "s3:ListBucket"},
+		{"s3.amazonaws.com", "ListObjectsV2", "s3:ListBucket"},
+		{"s3.amazonaws.com", "HeadObject", "s3:GetObject"},
+		{"s3.amazonaws.com", "HeadBucket", "s3:ListBucket"},
+		{"s3.amazonaws.com", "UploadPart", "s3:PutObject"},
+		{"monitoring.amazonaws.com", "PutMetricData", "cloudwatch:PutMetricData"},
+		{"lambda.amazonaws.com", "GetFunction20150331v2", "lambda:GetFunction"},
+		{"lambda.amazonaws.com", "Invoke", "lambda:InvokeFunction"},
+	}
+
+	for _, tt := range tests {
+		e := Event{EventSource: tt.source, EventName: tt.name}
+		assert.Equal(t, tt.expected, eventAction(e), "%s %s", tt.source, tt.name)
+	}
+}
@@ -0,0 +1,104 @@
+package cloudtrail
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// PolicyDocument is an IAM policy document as it is written to IAM
+type PolicyDocument struct {
+	Version   string      `json:"Version"`
+	Statement []Statement `json:"Statement"`
+}
+
+// Statement is an Allow statement of a suggested policy
+type Statement struct {
+	Effect   string   `json:"Effect"`
+	Action   []string `json:"Action"`
+	Resource []string `json:"Resource"`
+}
+
+// SuggestPolicy builds a policy allowing exactly the calls made. Actions
+// called on the same resources share a statement. An action also called
+// without naming a resource is allowed on *. Actions the action catalog does
+// not know are left out and returned as unknown, sorted.
+func SuggestPolicy(calls []types.APICall) (policy PolicyDocument, unknown []string) {
+	resources := make(map[string][]string)
+	var actions []string
+	skipped := make(map[string]bool)
+	for _, call := range calls {
+		if !KnownAction(call.Action) {
+			if !skipped[call.Action] {
+				skipped[call.Action] = true
+				unknown = append(unknown, call.Action)
+			}
+			continue
+		}
+		if _, ok := resources[call.Action]; !ok {
+			actions = append(actions, call.Action)
+		}
+		resources[call.Action] = append(resources[call.Action], call.Resource)
+	}
+	sort.Strings(actions)
+
+	var statements []Statement
+	byResources := make(map[string]int)
+	for _, action := range actions {
+		arns := dedupe(resources[action])
+		key := strings.Join(arns, "\n")
+		if i, ok := byResources[key]; ok {
+			statements[i].Action = append(statements[i].Action, action)
+			continue
+		}
+		byResources[key] = len(statements)
+		statements = append(statements, Statement{Effect: "Allow", Action: []string{action}, Resource: arns})
+	}
+
+	sort.Strings(unknown)
+	return PolicyDocument{Version: "2012-10-17", Statement: statements}, unknown
+}
+
+// KnownAction reports whether an action can go in a policy. Actions of the
+// services the action catalog covers must be listed in it; those of other
+// services cannot be checked and are trusted.
+func KnownAction(action string) bool {
+	service, _, _ := strings.Cut(action, ":")
+	if _, known := catalog.Default().Expand(service + ":*"); !known {
+		return true
+	}
+	_, ok := catalog.Default().Lookup(action)
+	return ok
+}
+
+// Permissions flattens the policy into one permission per action and
+// resource, the way the permissions of the role's policies are listed
+func (d PolicyDocument) Permissions() []types.PermissionDisplay {
+	var perms []types.PermissionDisplay
+	for _, s := range d.Statement {
+		for _, action := range s.Action {
+			for _, resource := range s.Resource {
+				perms = append(perms, types.PermissionDisplay{Action: action, Resource: resource, Effect: s.Effect})
+			}
+		}
+	}
+	return perms
+}
+
+// dedupe sorts resources and drops repeats, or returns * alone when it is
+// among them
+func dedupe(values []string) []string {
+	sort.Strings(values)
+	if values[0] == "*" {
+		return values[:1]
+	}
+	unique := values[:0]
+	for _, v := range values {
+		if len(unique) == 0 || v != unique[len(unique)-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,47 @@
+package cloudtrail
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestPolicy(t *testing.T) {
+	calls := []types.APICall{
+		{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/b.csv"},
+		{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a.csv"},
+		{Action: "s3:PutObject", Resource: "arn:aws:s3:::reports/a.csv"},
+		{Action: "s3:PutObject", Resource: "arn:aws:s3:::reports/b.csv"},
+		{Action: "sqs:SendMessage", Resource: "arn:aws:sqs:us-east-1:123456789012:jobs"},
+		{Action: "sqs:SendMessage", Resource: "*"},
+		{Action: "sts:GetCallerIdentity", Resource: "*"},
+		{Action: "s3:ListObjects", Resource: "arn:aws:s3:::reports"},
+		{Action: "s3:ListObjects", Resource: "arn:aws:s3:::archive"},
+		{Action: "xray:PutTraceSegments", Resource: "*"},
+	}
+
+	expected := PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []Statement{
+			{
+				Effect:   "Allow",
+				Action:   []string{"s3:GetObject", "s3:PutObject"},
+				Resource: []string{"arn:aws:s3:::reports/a.csv", "arn:aws:s3:::reports/b.csv"},
+			},
+			{
+				Effect:   "Allow",
+				Action:   []string{"sqs:SendMessage", "sts:GetCallerIdentity", "xray:PutTraceSegments"},
+				Resource: []string{"*"},
+			},
+		},
+	}
+
+	// Actions of services the catalog covers must be listed in it, those of
+	// other services are trusted
+	policy, unknown := SuggestPolicy(calls)
+	assert.Equal(t, expected, policy)
+	assert.Equal(t, []string{"s3:ListObjects"}, unknown)
+	assert.Len(t, policy.Permissions(), 7)
+	assert.Equal(t, types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a.csv", Effect: "Allow"}, policy.Permissions()[0])
+}
@@ -0,0 +1,177 @@
+// Package escalation detects known IAM privilege-escalation paths: actions
+// that, granted together, let a role obtain permissions beyond its own, such
+// as passing a more privileged role to an EC2 instance it launches. Paths are
+// detected across all of a role's policies combined, since the permissions
+// enabling one are often spread over several policies.
+package escalation
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// Requirement is an action a path needs granted
+type Requirement struct {
+	Action string // A concrete action, e.g. iam:PassRole
+	// Resource is a pattern the grant must cover, e.g. * for a path that
+	// needs the action on every resource. Empty when a grant on any resource
+	// enables the path.
+	Resource string
+}
+
+func (r Requirement) String() string {
+	if r.Resource == "" {
+		return r.Action
+	}
+	return r.Action + " on " + r.Resource
+}
+
+// Rule is a known privilege-escalation path
+type Rule struct {
+	Description string        // What the path lets the role do
+	Requires    []Requirement // Every one of them must be granted
+}
+
+// String names the path by the actions it needs, e.g.
+// "iam:PassRole + ec2:RunInstances"
+func (r Rule) String() string {
+	names := make([]string, len(r.Requires))
+	for i, req := range r.Requires {
+		names[i] = req.String()
+	}
+	return strings.Join(names, " + ")
+}
+
+// requires builds the requirements of a path that needs each action on any
+// resource
+func requires(actions ...string) []Requirement {
+	reqs := make([]Requirement, len(actions))
+	for i, action := range actions {
+		reqs[i] = Requirement{Action: action}
+	}
+	return reqs
+}
+
+// Rules are the privilege-escalation paths detected, after the methods
+// catalogued by Rhino Security Labs
+var Rules = []Rule{
+	{Description: "publish a new default version of a managed policy that grants anything", Requires: requires("iam:CreatePolicyVersion")},
+	{Description: "make an older, more permissive version of a managed policy the default", Requires: requires("iam:SetDefaultPolicyVersion")},
+	{Description: "attach any managed policy, such as AdministratorAccess, to a role", Requires: requires("iam:AttachRolePolicy")},
+	{Description: "add an inline policy that grants anything to a role", Requires: requires("iam:PutRolePolicy")},
+	{Description: "let itself assume a more privileged role by changing the role's trust policy", Requires: requires("iam:UpdateAssumeRolePolicy", "sts:AssumeRole")},
+	{Description: "create access keys for a more privileged IAM user", Requires: requires("iam:CreateAccessKey")},
+	{Description: "set a console password for a more privileged IAM user", Requires: requires("iam:CreateLoginProfile")},
+	{Description: "change the console password of a more privileged IAM user", Requires: requires("iam:UpdateLoginProfile")},
+	{Description: "launch an instance with a more privileged role and use its credentials", Requires: requires("iam:PassRole", "ec2:RunInstances")},
+	{Description: "create and invoke a function that runs as a more privileged role", Requires: requires("iam:PassRole", "lambda:CreateFunction", "lambda:InvokeFunction")},
+	{Description: "run a task that runs as a more privileged role", Requires: requires("iam:PassRole", "ecs:RegisterTaskDefinition", "ecs:RunTask")},
+	{Description: "create a stack that acts as a more privileged role", Requires: requires("iam:PassRole", "cloudformation:CreateStack")},
+	{Description: "create a Glue development endpoint that runs as a more privileged role", Requires: requires("iam:PassRole", "glue:CreateDevEndpoint")},
+	{Description: "replace the code of a function that runs as a more privileged role", Requires: requires("lambda:UpdateFunctionCode")},
+	{Description: "add an SSH key to a Glue development endpoint and use its role", Requires: requires("glue:UpdateDevEndpoint")},
+	{Description: "run commands on instances and use their roles", Requires: requires("ssm:SendCommand")},
+	{Description: "assume any role that trusts the account", Requires: []Requirement{{Action: "sts:AssumeRole", Resource: "*"}}},
+}
+
+// Detect returns the paths of the rules that the policies enable, each with
+// the permissions enabling it. A path is conditional when any of the actions
+// it needs is only granted under conditions, or a Deny under conditions may
+// take it away. Requirements an unconditional Deny takes away, and grants
+// whose conditions fail in the request context, enable nothing.
+func Detect(policies []types.Policy, rules []Rule) []types.EscalationPath {
+	var paths []types.EscalationPath
+	for _, rule := range rules {
+		path := types.EscalationPath{Path: rule.String(), Description: rule.Description}
+
+		enabled := true
+		for _, req := range rule.Requires {
+			grants, conditional := granted(policies, req)
+			if len(grants) == 0 {
+				enabled = false
+				break
+			}
+			for _, g := range grants {
+				// A grant such as lambda:* may enable several requirements
+				if !containsGrant(path.Grants, g) {
+					path.Grants = append(path.Grants, g)
+				}
+			}
+			path.Conditional = path.Conditional || conditional
+		}
+
+		if enabled {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// granted returns the Allow permissions granting a requirement that no
+// unconditional Deny takes away. conditional is set when all of them only
+// apply under conditions, or a Deny under conditions may take the
+// requirement away.
+func granted(policies []types.Policy, req Requirement) (grants []types.Grant, conditional bool) {
+	probe := types.PermissionDisplay{Action: req.Action, Resource: req.Resource}
+	if req.Resource == "" {
+		probe.Resource = "*"
+	}
+
+	conditional = true
+	for _, policy := range policies {
+		for _, p := range policy.Permissions {
+			if p.Effect != "Allow" || p.IsInapplicable() {
+				continue
+			}
+			if req.Resource == "" && !evaluator.Overlaps(p, probe) {
+				continue
+			}
+			if req.Resource != "" && !evaluator.Covers(p, probe) {
+				continue
+			}
+
+			// Only the requirement's action of the grant must survive the
+			// Denies, on the resources granted
+			granted := types.PermissionDisplay{Action: req.Action, Resource: p.Resource, NotResource: p.NotResource}
+			denied, deniedConditionally := denies(policies, granted)
+			if denied {
+				continue
+			}
+
+			grants = append(grants, types.Grant{Policy: policy.Name, Permission: p})
+			if !p.IsConditional() && !deniedConditionally {
+				conditional = false
+			}
+		}
+	}
+	return grants, conditional
+}
+
+// denies reports whether an unconditional Deny covers a permission, or
+// whether a Deny under conditions may
+func denies(policies []types.Policy, p types.PermissionDisplay) (denied, conditionally bool) {
+	for _, policy := range policies {
+		for _, d := range policy.Permissions {
+			if d.Effect != "Deny" || d.IsInapplicable() || !evaluator.Covers(d, p) {
+				continue
+			}
+			if !d.IsConditional() {
+				return true, false
+			}
+			conditionally = true
+		}
+	}
+	return false, conditionally
+}
+
+func containsGrant(grants []types.Grant, g types.Grant) bool {
+	for _, existing := range grants {
+		if reflect.DeepEqual(existing, g) {
+			return true
+		}
+	}
+	return false
+}
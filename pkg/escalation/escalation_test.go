@@ -0,0 +1,157 @@
+package escalation
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	allow := func(action, resource string) types.PermissionDisplay {
+		return types.PermissionDisplay{Action: action, Resource: resource, Effect: "Allow"}
+	}
+	deny := func(action, resource string) types.PermissionDisplay {
+		return types.PermissionDisplay{Action: action, Resource: resource, Effect: "Deny"}
+	}
+	vpc := []types.Condition{{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}}}
+
+	tests := []struct {
+		name        string
+		policies    []types.Policy
+		paths       []string
+		conditional bool
+	}{
+		{
+			name: "pass role spread over two policies",
+			policies: []types.Policy{
+				{Name: "pass", Permissions: []types.PermissionDisplay{allow("iam:PassRole", "arn:aws:iam::123456789012:role/admin")}},
+				{Name: "ec2", Permissions: []types.PermissionDisplay{allow("ec2:*", "*")}},
+			},
+			paths: []string{"iam:PassRole + ec2:RunInstances"},
+		},
+		{
+			name: "pass role alone",
+			policies: []types.Policy{
+				{Name: "pass", Permissions: []types.PermissionDisplay{allow("iam:PassRole", "*")}},
+			},
+		},
+		{
+			name: "wildcard actions",
+			policies: []types.Policy{
+				{Name: "iam", Permissions: []types.PermissionDisplay{allow("iam:*Policy*", "*")}},
+			},
+			paths: []string{
+				"iam:CreatePolicyVersion",
+				"iam:SetDefaultPolicyVersion",
+				"iam:AttachRolePolicy",
+				"iam:PutRolePolicy",
+			},
+		},
+		{
+			name: "assume role on a single role",
+			policies: []types.Policy{
+				{Name: "assume", Permissions: []types.PermissionDisplay{allow("sts:AssumeRole", "arn:aws:iam::123456789012:role/reader")}},
+			},
+		},
+		{
+			name: "assume role on every role",
+			policies: []types.Policy{
+				{Name: "assume", Permissions: []types.PermissionDisplay{allow("sts:AssumeRole", "*")}},
+			},
+			paths: []string{"sts:AssumeRole on *"},
+		},
+		{
+			name: "denied",
+			policies: []types.Policy{
+				{Name: "lambda", Permissions: []types.PermissionDisplay{allow("lambda:*", "*")}},
+				{Name: "guardrails", Permissions: []types.PermissionDisplay{deny("lambda:Update*", "*")}},
+			},
+		},
+		{
+			name: "denied on other resources",
+			policies: []types.Policy{
+				{Name: "lambda", Permissions: []types.PermissionDisplay{allow("lambda:UpdateFunctionCode", "*")}},
+				{Name: "guardrails", Permissions: []types.PermissionDisplay{deny("lambda:UpdateFunctionCode", "arn:aws:lambda:*:*:function:prod-*")}},
+			},
+			paths: []string{"lambda:UpdateFunctionCode"},
+		},
+		{
+			name: "conditional grant",
+			policies: []types.Policy{
+				{Name: "ssm", Permissions: []types.PermissionDisplay{
+					{Action: "ssm:SendCommand", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: vpc},
+				}},
+			},
+			paths:       []string{"ssm:SendCommand"},
+			conditional: true,
+		},
+		{
+			name: "grant whose conditions fail",
+			policies: []types.Policy{
+				{Name: "ssm", Permissions: []types.PermissionDisplay{
+					{Action: "ssm:SendCommand", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: vpc, ConditionResult: types.ConditionsFail},
+				}},
+			},
+		},
+		{
+			name: "excluded by NotAction",
+			policies: []types.Policy{
+				{Name: "power-user", Permissions: []types.PermissionDisplay{{Action: "iam:*", Resource: "*", Effect: "Allow", NotAction: true}}},
+			},
+			paths: []string{
+				"lambda:UpdateFunctionCode",
+				"glue:UpdateDevEndpoint",
+				"ssm:SendCommand",
+				"sts:AssumeRole on *",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := Detect(tt.policies, Rules)
+
+			var names []string
+			for _, p := range paths {
+				names = append(names, p.Path)
+				assert.NotEmpty(t, p.Grants)
+				assert.Equal(t, tt.conditional, p.Conditional)
+			}
+			assert.Equal(t, tt.paths, names)
+		})
+	}
+}
+
+func TestDetect_Grants(t *testing.T) {
+	pass := types.PermissionDisplay{Action: "iam:PassRole", Resource: "*", Effect: "Allow"}
+	run := types.PermissionDisplay{Action: "ec2:RunInstances", Resource: "*", Effect: "Allow"}
+	policies := []types.Policy{
+		{Name: "pass", Permissions: []types.PermissionDisplay{pass}},
+		{Name: "ec2", Permissions: []types.PermissionDisplay{run}},
+	}
+
+	paths := Detect(policies, Rules)
+	assert.Len(t, paths, 1)
+	assert.Equal(t, "launch an instance with a more privileged role and use its credentials", paths[0].Description)
+	assert.Equal(t, []types.Grant{{Policy: "pass", Permission: pass}, {Policy: "ec2", Permission: run}}, paths[0].Grants)
+
+	// A grant enabling several requirements is listed once
+	lambda := types.PermissionDisplay{Action: "lambda:*", Resource: "*", Effect: "Allow"}
+	paths = Detect([]types.Policy{{Name: "pass", Permissions: []types.PermissionDisplay{pass}}, {Name: "lambda", Permissions: []types.PermissionDisplay{lambda}}}, Rules)
+	assert.Len(t, paths, 2)
+	assert.Equal(t, "iam:PassRole + lambda:CreateFunction + lambda:InvokeFunction", paths[0].Path)
+	assert.Equal(t, []types.Grant{{Policy: "pass", Permission: pass}, {Policy: "lambda", Permission: lambda}}, paths[0].Grants)
+}
+
+func TestRules_Catalogued(t *testing.T) {
+	// Every action a path requires is in the action catalog, so wildcards
+	// granting it are expanded rather than guessed at
+	for _, rule := range Rules {
+		for _, req := range rule.Requires {
+			_, ok := catalog.Default().Lookup(req.Action)
+			assert.True(t, ok, req.Action)
+		}
+	}
+}
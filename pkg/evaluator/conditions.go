@@ -0,0 +1,289 @@
+package evaluator
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// Context holds the values of the condition keys of a request, such as
+// aws:SourceVpc or aws:PrincipalTag/team. A key may have several values,
+// like aws:TagKeys. Keys missing from the context are absent from the
+// request, as they are in the AWS policy simulator.
+type Context map[string][]string
+
+// NewContext builds a request context from condition key values. Condition
+// keys are case-insensitive. It returns nil when there are no values, so
+// that conditions stay unresolved.
+func NewContext(values map[string][]string) Context {
+	if len(values) == 0 {
+		return nil
+	}
+	ctx := make(Context, len(values))
+	for key, vals := range values {
+		key = strings.ToLower(key)
+		ctx[key] = append(ctx[key], vals...)
+	}
+	return ctx
+}
+
+func (c Context) lookup(key string) ([]string, bool) {
+	values, ok := c[strings.ToLower(key)]
+	return values, ok && len(values) > 0
+}
+
+// operator is a condition operator: a test of a request value against one
+// of the statement's values, and whether the operator negates it
+type operator struct {
+	match   func(value, expected string) bool
+	negated bool
+}
+
+// operators are the condition operators that can be evaluated locally,
+// keyed by their lowercase name
+var operators = map[string]operator{
+	"stringequals":              {stringEquals, false},
+	"stringnotequals":           {stringEquals, true},
+	"stringequalsignorecase":    {strings.EqualFold, false},
+	"stringnotequalsignorecase": {strings.EqualFold, true},
+	"stringlike":                {stringLike, false},
+	"stringnotlike":             {stringLike, true},
+	"arnequals":                 {arnLike, false},
+	"arnlike":                   {arnLike, false},
+	"arnnotequals":              {arnLike, true},
+	"arnnotlike":                {arnLike, true},
+	"ipaddress":                 {ipAddress, false},
+	"notipaddress":              {ipAddress, true},
+	"bool":                      {boolEquals, false},
+	"numericequals":             {numeric(func(a, b float64) bool { return a == b }), false},
+	"numericnotequals":          {numeric(func(a, b float64) bool { return a == b }), true},
+	"numericlessthan":           {numeric(func(a, b float64) bool { return a < b }), false},
+	"numericlessthanequals":     {numeric(func(a, b float64) bool { return a <= b }), false},
+	"numericgreaterthan":        {numeric(func(a, b float64) bool { return a > b }), false},
+	"numericgreaterthanequals":  {numeric(func(a, b float64) bool { return a >= b }), false},
+}
+
+// policyVariable matches a policy variable such as ${aws:username}
+var policyVariable = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// Resolve returns a copy of the policies in which every permission with
+// conditions records whether they hold in the request context. Permissions
+// with conditions that cannot be evaluated locally, such as date operators,
+// stay conditional. A nil context leaves the policies untouched.
+func Resolve(policies []types.Policy, ctx Context) []types.Policy {
+	if ctx == nil {
+		return policies
+	}
+
+	resolved := make([]types.Policy, len(policies))
+	for i, policy := range policies {
+		resolved[i] = policy
+		if policy.Permissions == nil {
+			continue
+		}
+
+		perms := make([]types.PermissionDisplay, len(policy.Permissions))
+		for j, p := range policy.Permissions {
+			p.ConditionResult = ""
+			if len(p.Conditions) > 0 {
+				if holds, known := EvaluateConditions(p.Conditions, ctx); known {
+					p.ConditionResult = types.ConditionsFail
+					if holds {
+						p.ConditionResult = types.ConditionsHold
+					}
+				}
+			}
+			perms[j] = p
+		}
+		resolved[i].Permissions = perms
+	}
+
+	return resolved
+}
+
+// EvaluateConditions reports whether all of a statement's conditions hold in
+// the request context. known is false when the outcome depends on a
+// condition that cannot be evaluated locally.
+func EvaluateConditions(conditions []types.Condition, ctx Context) (holds, known bool) {
+	holds, known = true, true
+	for _, c := range conditions {
+		h, k := EvaluateCondition(c, ctx)
+		switch {
+		case k && !h:
+			// One failing condition decides the statement
+			return false, true
+		case !k:
+			holds, known = false, false
+		}
+	}
+	return holds, known
+}
+
+// EvaluateCondition reports whether a single condition holds in the request
+// context. known is false for operators that cannot be evaluated locally and
+// for values referencing policy variables the context does not define.
+func EvaluateCondition(c types.Condition, ctx Context) (holds, known bool) {
+	name := strings.ToLower(c.Operator)
+	qualifier := ""
+	if prefix, rest, found := strings.Cut(name, ":"); found {
+		qualifier, name = prefix, rest
+	}
+	ifExists := strings.HasSuffix(name, "ifexists")
+	name = strings.TrimSuffix(name, "ifexists")
+
+	values, present := ctx.lookup(c.Key)
+
+	// Null tests whether the key is absent rather than its value
+	if name == "null" {
+		for _, v := range c.Values {
+			if absent, err := strconv.ParseBool(v); err == nil && absent != present {
+				return true, true
+			}
+		}
+		return false, true
+	}
+
+	op, ok := operators[name]
+	if !ok {
+		return false, false
+	}
+
+	if !present {
+		// IfExists and ForAllValues hold when there is nothing to test, and
+		// so do negated operators since no value matches. ForAnyValue needs
+		// a value to hold.
+		if qualifier == "foranyvalue" {
+			return false, true
+		}
+		return ifExists || qualifier == "forallvalues" || op.negated, true
+	}
+
+	expected, ok := substituteVariables(c.Values, ctx)
+	if !ok {
+		return false, false
+	}
+
+	// A request value matches when it passes the test against any of the
+	// statement's values
+	matches := func(value string) bool {
+		for _, e := range expected {
+			if op.match(value, e) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch qualifier {
+	case "forallvalues":
+		for _, v := range values {
+			if matches(v) == op.negated {
+				return false, true
+			}
+		}
+		return true, true
+	case "foranyvalue":
+		for _, v := range values {
+			if matches(v) != op.negated {
+				return true, true
+			}
+		}
+		return false, true
+	}
+
+	// A negated operator holds when no value matches, any other when one does
+	for _, v := range values {
+		if matches(v) {
+			return !op.negated, true
+		}
+	}
+	return op.negated, true
+}
+
+// substituteVariables replaces policy variables in condition values with
+// their value in the request context. ok is false when a variable has no
+// single value in the context, or is one of the escapes ${*}, ${?} and ${$}.
+func substituteVariables(values []string, ctx Context) (substituted []string, ok bool) {
+	ok = true
+	substituted = make([]string, len(values))
+	for i, v := range values {
+		substituted[i] = policyVariable.ReplaceAllStringFunc(v, func(variable string) string {
+			key := policyVariable.FindStringSubmatch(variable)[1]
+			// Variables may name a default, as in ${aws:username, 'anonymous'}
+			key, fallback, hasFallback := strings.Cut(key, ",")
+			key = strings.TrimSpace(key)
+
+			if value, found := ctx.lookup(key); found && len(value) == 1 {
+				return value[0]
+			}
+			if hasFallback {
+				return strings.Trim(strings.TrimSpace(fallback), "'")
+			}
+			ok = false
+			return variable
+		})
+	}
+	return substituted, ok
+}
+
+func stringEquals(value, expected string) bool {
+	return value == expected
+}
+
+func stringLike(value, pattern string) bool {
+	return matchPattern(pattern, value)
+}
+
+// arnLike matches an ARN against a pattern component by component, so that
+// wildcards do not span the colons separating them
+func arnLike(value, pattern string) bool {
+	values := strings.SplitN(value, ":", 6)
+	patterns := strings.SplitN(pattern, ":", 6)
+	if len(values) != 6 || len(patterns) != 6 {
+		return false
+	}
+	for i := range values {
+		if !matchPattern(patterns[i], values[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ipAddress reports whether an IP address lies within a CIDR block or equals
+// a single address
+func ipAddress(value, expected string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	if _, block, err := net.ParseCIDR(expected); err == nil {
+		return block.Contains(ip)
+	}
+	return ip.Equal(net.ParseIP(expected))
+}
+
+func boolEquals(value, expected string) bool {
+	a, err := strconv.ParseBool(value)
+	if err != nil {
+		return false
+	}
+	b, err := strconv.ParseBool(expected)
+	return err == nil && a == b
+}
+
+// numeric builds a numeric test comparing the request value to the
+// statement's value
+func numeric(compare func(value, expected float64) bool) func(string, string) bool {
+	return func(value, expected string) bool {
+		a, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false
+		}
+		b, err := strconv.ParseFloat(expected, 64)
+		return err == nil && compare(a, b)
+	}
+}
@@ -0,0 +1,308 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateCondition(t *testing.T) {
+	ctx := NewContext(map[string][]string{
+		"aws:SourceVpc":          {"vpc-123"},
+		"aws:PrincipalTag/team":  {"payments"},
+		"aws:PrincipalArn":       {"arn:aws:iam::123456789012:role/api"},
+		"aws:SourceIp":           {"10.0.1.7"},
+		"aws:SecureTransport":    {"true"},
+		"aws:TagKeys":            {"team", "env"},
+		"aws:MultiFactorAuthAge": {"600"},
+		"aws:username":           {"alice"},
+	})
+
+	tests := []struct {
+		name      string
+		condition types.Condition
+		holds     bool
+		known     bool
+	}{
+		{
+			name:      "string equals",
+			condition: types.Condition{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-999", "vpc-123"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "keys are case-insensitive",
+			condition: types.Condition{Operator: "StringEquals", Key: "AWS:SOURCEVPC", Values: []string{"vpc-123"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "string not equals",
+			condition: types.Condition{Operator: "StringNotEquals", Key: "aws:PrincipalTag/team", Values: []string{"payments"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "string like",
+			condition: types.Condition{Operator: "StringLike", Key: "aws:PrincipalTag/team", Values: []string{"pay*"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "arn like does not span components",
+			condition: types.Condition{Operator: "ArnLike", Key: "aws:PrincipalArn", Values: []string{"arn:aws:iam::*:role/*"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "arn like on another account",
+			condition: types.Condition{Operator: "ArnLike", Key: "aws:PrincipalArn", Values: []string{"arn:aws:iam::999999999999:role/*"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "ip address in range",
+			condition: types.Condition{Operator: "IpAddress", Key: "aws:SourceIp", Values: []string{"10.0.0.0/16"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "not ip address",
+			condition: types.Condition{Operator: "NotIpAddress", Key: "aws:SourceIp", Values: []string{"10.0.0.0/16"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "bool",
+			condition: types.Condition{Operator: "Bool", Key: "aws:SecureTransport", Values: []string{"false"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "numeric",
+			condition: types.Condition{Operator: "NumericLessThan", Key: "aws:MultiFactorAuthAge", Values: []string{"3600"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "missing key",
+			condition: types.Condition{Operator: "StringEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "missing key with IfExists",
+			condition: types.Condition{Operator: "StringEqualsIfExists", Key: "aws:SourceVpce", Values: []string{"vpce-1"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "present key with IfExists",
+			condition: types.Condition{Operator: "StringEqualsIfExists", Key: "aws:SourceVpc", Values: []string{"vpc-999"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "StringNotEquals on missing key",
+			condition: types.Condition{Operator: "StringNotEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "StringNotEqualsIgnoreCase on missing key",
+			condition: types.Condition{Operator: "StringNotEqualsIgnoreCase", Key: "aws:SourceVpce", Values: []string{"vpce-1"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "StringNotLike on missing key",
+			condition: types.Condition{Operator: "StringNotLike", Key: "aws:SourceVpce", Values: []string{"vpce-*"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "ArnNotEquals on missing key",
+			condition: types.Condition{Operator: "ArnNotEquals", Key: "aws:SourceArn", Values: []string{"arn:aws:iam::123456789012:role/api"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "ArnNotLike on missing key",
+			condition: types.Condition{Operator: "ArnNotLike", Key: "aws:SourceArn", Values: []string{"arn:aws:iam::*:role/api"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "NotIpAddress on missing key",
+			condition: types.Condition{Operator: "NotIpAddress", Key: "aws:VpcSourceIp", Values: []string{"10.0.0.0/8"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "NumericNotEquals on missing key",
+			condition: types.Condition{Operator: "NumericNotEquals", Key: "s3:max-keys", Values: []string{"3"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "for any value negated on missing key",
+			condition: types.Condition{Operator: "ForAnyValue:StringNotEquals", Key: "aws:RequestTag/env", Values: []string{"prod"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "null on missing key",
+			condition: types.Condition{Operator: "Null", Key: "aws:SourceVpce", Values: []string{"true"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "null on present key",
+			condition: types.Condition{Operator: "Null", Key: "aws:SourceVpc", Values: []string{"true"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "for any value",
+			condition: types.Condition{Operator: "ForAnyValue:StringEquals", Key: "aws:TagKeys", Values: []string{"env"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "for all values",
+			condition: types.Condition{Operator: "ForAllValues:StringEquals", Key: "aws:TagKeys", Values: []string{"env"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "for all values on missing key",
+			condition: types.Condition{Operator: "ForAllValues:StringEquals", Key: "aws:RequestTag/env", Values: []string{"prod"}},
+			holds:     true,
+			known:     true,
+		},
+		{
+			name:      "for any value on missing key",
+			condition: types.Condition{Operator: "ForAnyValue:StringEquals", Key: "aws:RequestTag/env", Values: []string{"prod"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "policy variable",
+			condition: types.Condition{Operator: "StringLike", Key: "aws:PrincipalTag/team", Values: []string{"${aws:username}"}},
+			holds:     false,
+			known:     true,
+		},
+		{
+			name:      "undefined policy variable",
+			condition: types.Condition{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"${aws:userid}"}},
+			known:     false,
+		},
+		{
+			name:      "unsupported operator",
+			condition: types.Condition{Operator: "DateGreaterThan", Key: "aws:CurrentTime", Values: []string{"2024-01-01T00:00:00Z"}},
+			known:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holds, known := EvaluateCondition(tt.condition, ctx)
+			assert.Equal(t, tt.known, known)
+			if tt.known {
+				assert.Equal(t, tt.holds, holds)
+			}
+		})
+	}
+}
+
+func TestEvaluateConditions(t *testing.T) {
+	ctx := NewContext(map[string][]string{"aws:SourceVpc": {"vpc-123"}})
+
+	vpc := types.Condition{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}}
+	otherVpc := types.Condition{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-999"}}
+	date := types.Condition{Operator: "DateLessThan", Key: "aws:CurrentTime", Values: []string{"2030-01-01T00:00:00Z"}}
+
+	holds, known := EvaluateConditions([]types.Condition{vpc}, ctx)
+	assert.True(t, holds)
+	assert.True(t, known)
+
+	// A failing condition decides the outcome even next to one that cannot
+	// be evaluated
+	holds, known = EvaluateConditions([]types.Condition{date, otherVpc}, ctx)
+	assert.False(t, holds)
+	assert.True(t, known)
+
+	_, known = EvaluateConditions([]types.Condition{vpc, date}, ctx)
+	assert.False(t, known)
+}
+
+func TestResolve(t *testing.T) {
+	policies := []types.Policy{
+		{
+			Name: "app",
+			Permissions: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "*", Effect: "Allow"},
+				{Action: "s3:PutObject", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: []types.Condition{
+					{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}},
+				}},
+				{Action: "s3:DeleteObject", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: []types.Condition{
+					{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-999"}},
+				}},
+				{Action: "kms:Decrypt", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: []types.Condition{
+					{Operator: "DateLessThan", Key: "aws:CurrentTime", Values: []string{"2030-01-01T00:00:00Z"}},
+				}},
+			},
+		},
+	}
+
+	// Without a context conditions stay unresolved
+	assert.Equal(t, policies, Resolve(policies, NewContext(nil)))
+
+	resolved := Resolve(policies, NewContext(map[string][]string{"aws:SourceVpc": {"vpc-123"}}))
+	perms := resolved[0].Permissions
+	assert.Empty(t, perms[0].ConditionResult)
+	assert.Equal(t, types.ConditionsHold, perms[1].ConditionResult)
+	assert.Equal(t, types.ConditionsFail, perms[2].ConditionResult)
+	assert.Empty(t, perms[3].ConditionResult)
+
+	// The input is not modified
+	assert.Empty(t, policies[0].Permissions[1].ConditionResult)
+
+	tests := []struct {
+		action      string
+		decision    string
+		conditional bool
+		unmet       int
+	}{
+		{action: "s3:PutObject", decision: DecisionAllowed},
+		{action: "s3:DeleteObject", decision: DecisionImplicitDeny, unmet: 1},
+		{action: "kms:Decrypt", decision: DecisionAllowed, conditional: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			result := Evaluate(resolved, tt.action, "*")
+			assert.Equal(t, tt.decision, result.Decision)
+			assert.Equal(t, tt.conditional, result.Conditional)
+			assert.Len(t, result.Unmet, tt.unmet)
+		})
+	}
+}
+
+func TestResolve_NegatedGuardrail(t *testing.T) {
+	// A guardrail denying requests that do not come through the VPC endpoint
+	// applies to requests without the endpoint key at all
+	policies := []types.Policy{
+		{Name: "app", Permissions: []types.PermissionDisplay{{Action: "s3:*", Resource: "*", Effect: "Allow"}}},
+		{Name: "guardrail", Permissions: []types.PermissionDisplay{
+			{Action: "s3:*", Resource: "*", Effect: "Deny", HasCondition: true, Conditions: []types.Condition{
+				{Operator: "StringNotEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1"}},
+			}},
+		}},
+	}
+
+	resolved := Resolve(policies, NewContext(map[string][]string{"aws:SourceVpc": {"vpc-1"}}))
+	assert.Equal(t, types.ConditionsHold, resolved[1].Permissions[0].ConditionResult)
+	assert.Equal(t, DecisionExplicitDeny, Evaluate(resolved, "s3:GetObject", "arn:aws:s3:::reports/a").Decision)
+}
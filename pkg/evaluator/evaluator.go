@@ -0,0 +1,231 @@
+// Package evaluator decides what a set of IAM policies effectively allows,
+// following the AWS policy evaluation logic: an explicit Deny in any policy
+// overrides every Allow, and a request no policy allows is implicitly denied.
+package evaluator
+
+import (
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// Outcomes of evaluating a request against a set of policies
+const (
+	DecisionAllowed      = "allowed"
+	DecisionExplicitDeny = "explicitly denied"
+	DecisionImplicitDeny = "implicitly denied"
+)
+
+// Match is a permission of a policy that applies to an evaluated request
+type Match struct {
+	Policy     string
+	Permission types.PermissionDisplay
+}
+
+// Result is the decision for a single action on a single resource together
+// with the permissions that led to it
+type Result struct {
+	Decision string
+	// Conditional is set when the decision depends on statement conditions
+	// that cannot be resolved locally
+	Conditional bool
+	Allows      []Match
+	Denies      []Match
+	// Unmet lists the permissions matching the request whose conditions do
+	// not hold in the request context, so they play no part in the decision
+	Unmet []Match
+	// OutsideBoundary is set when the policies allow the request but the
+	// permissions boundary does not
+	OutsideBoundary bool
+}
+
+// Evaluate decides whether the policies allow action on resource. A Deny
+// with conditions only denies when they hold, so on its own it makes the
+// result conditional rather than denied; the same goes for an Allow.
+// Conditions resolved against a request context by Resolve are honored:
+// permissions whose conditions hold apply unconditionally, and those whose
+// conditions fail do not apply at all.
+func Evaluate(policies []types.Policy, action, resource string) Result {
+	var result Result
+
+	for _, policy := range policies {
+		for _, p := range policy.Permissions {
+			if !Applies(p, action, resource) {
+				continue
+			}
+
+			match := Match{Policy: policy.Name, Permission: p}
+			if p.IsInapplicable() {
+				result.Unmet = append(result.Unmet, match)
+				continue
+			}
+			switch p.Effect {
+			case "Deny":
+				result.Denies = append(result.Denies, match)
+			case "Allow":
+				result.Allows = append(result.Allows, match)
+			}
+		}
+	}
+
+	unconditional := func(matches []Match) bool {
+		for _, m := range matches {
+			if !m.Permission.IsConditional() {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case unconditional(result.Denies):
+		result.Decision = DecisionExplicitDeny
+	case unconditional(result.Allows):
+		result.Decision = DecisionAllowed
+		result.Conditional = len(result.Denies) > 0
+	case len(result.Allows) > 0:
+		result.Decision = DecisionAllowed
+		result.Conditional = true
+	default:
+		result.Decision = DecisionImplicitDeny
+	}
+
+	return result
+}
+
+// EvaluateBounded decides whether identity policies capped by a permissions
+// boundary allow action on resource. The request is only allowed when both
+// the policies and the boundary allow it, and a Deny in either denies it.
+// The boundary may be nil. Only the policies' Allows are reported, since the
+// boundary grants nothing itself.
+func EvaluateBounded(policies []types.Policy, boundary *types.Policy, action, resource string) Result {
+	result := Evaluate(policies, action, resource)
+	if boundary == nil {
+		return result
+	}
+
+	bounded := Evaluate([]types.Policy{*boundary}, action, resource)
+	result.Denies = append(result.Denies, bounded.Denies...)
+	result.Unmet = append(result.Unmet, bounded.Unmet...)
+
+	switch {
+	case result.Decision == DecisionExplicitDeny:
+	case bounded.Decision == DecisionExplicitDeny:
+		result.Decision = DecisionExplicitDeny
+		result.Conditional = false
+	case result.Decision == DecisionAllowed && bounded.Decision == DecisionImplicitDeny:
+		result.Decision = DecisionImplicitDeny
+		result.Conditional = false
+		result.OutsideBoundary = true
+	case result.Decision == DecisionAllowed:
+		result.Conditional = result.Conditional || bounded.Conditional
+	}
+
+	return result
+}
+
+// Annotate returns a copy of the policies in which every Allow that an
+// explicit Deny of any of the policies overrides is marked with the Deny's
+// policy. The Allow is fully denied when an unconditional Deny covers all of
+// its actions and resources, and partially denied when a Deny covers only
+// some of them or only applies under conditions. Denies whose conditions
+// fail in the request context override nothing.
+func Annotate(policies []types.Policy) []types.Policy {
+	var denies []Match
+	for _, policy := range policies {
+		for _, p := range policy.Permissions {
+			if p.Effect == "Deny" && !p.IsInapplicable() {
+				denies = append(denies, Match{Policy: policy.Name, Permission: p})
+			}
+		}
+	}
+
+	annotated := make([]types.Policy, len(policies))
+	for i, policy := range policies {
+		annotated[i] = policy
+		if len(denies) == 0 || policy.Permissions == nil {
+			continue
+		}
+
+		perms := make([]types.PermissionDisplay, len(policy.Permissions))
+		for j, p := range policy.Permissions {
+			if p.Effect == "Allow" {
+				p.Denial, p.DeniedBy = denial(p, denies)
+			}
+			perms[j] = p
+		}
+		annotated[i].Permissions = perms
+	}
+
+	return annotated
+}
+
+// denial finds the strongest Deny overriding an Allow
+func denial(allow types.PermissionDisplay, denies []Match) (string, string) {
+	for _, d := range denies {
+		if !d.Permission.IsConditional() && Covers(d.Permission, allow) {
+			return types.DenialFull, d.Policy
+		}
+	}
+
+	for _, d := range denies {
+		if Overlaps(d.Permission, allow) {
+			return types.DenialPartial, d.Policy
+		}
+	}
+
+	return "", ""
+}
+
+// Applies reports whether a permission's action and resource patterns match
+// a request, honoring NotAction and NotResource
+func Applies(p types.PermissionDisplay, action, resource string) bool {
+	return MatchAction(p.Action, action) != p.NotAction &&
+		MatchResource(p.Resource, resource) != p.NotResource
+}
+
+// Covers reports whether outer applies to every request inner applies to,
+// judging by actions and resources only
+func Covers(outer, inner types.PermissionDisplay) bool {
+	return covers(outer.Action, outer.NotAction, inner.Action, inner.NotAction, MatchAction, OverlapAction) &&
+		covers(outer.Resource, outer.NotResource, inner.Resource, inner.NotResource, MatchResource, OverlapResource)
+}
+
+// Overlaps reports whether some request is matched by both permissions,
+// judging by actions and resources only
+func Overlaps(a, b types.PermissionDisplay) bool {
+	return overlaps(a.Action, a.NotAction, b.Action, b.NotAction, MatchAction, OverlapAction) &&
+		overlaps(a.Resource, a.NotResource, b.Resource, b.NotResource, MatchResource, OverlapResource)
+}
+
+// covers reports whether the set of values described by the outer pattern
+// contains the set described by the inner one. A negated pattern describes
+// every value it does not match.
+func covers(outer string, outerNot bool, inner string, innerNot bool, match, overlap func(a, b string) bool) bool {
+	switch {
+	case !outerNot && !innerNot:
+		return match(outer, inner)
+	case !outerNot && innerNot:
+		// Only a full wildcard contains nearly everything
+		return outer == "*"
+	case outerNot && !innerNot:
+		return !overlap(outer, inner)
+	default:
+		// Excluding less keeps more
+		return match(inner, outer)
+	}
+}
+
+// overlaps reports whether the sets of values described by two patterns
+// share a value
+func overlaps(a string, aNot bool, b string, bNot bool, match, overlap func(a, b string) bool) bool {
+	switch {
+	case !aNot && !bNot:
+		return overlap(a, b)
+	case !aNot && bNot:
+		return !match(b, a)
+	case aNot && !bNot:
+		return !match(a, b)
+	default:
+		// Two exclusions always leave values in common
+		return true
+	}
+}
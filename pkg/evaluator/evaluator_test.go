@@ -0,0 +1,269 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluate(t *testing.T) {
+	policies := []types.Policy{
+		{
+			Name: "s3-access",
+			Permissions: []types.PermissionDisplay{
+				{Action: "s3:*", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+				{Action: "kms:Decrypt", Resource: "*", Effect: "Allow", HasCondition: true},
+			},
+		},
+		{
+			Name: "guardrails",
+			Permissions: []types.PermissionDisplay{
+				{Action: "s3:DeleteObject", Resource: "*", Effect: "Deny"},
+				{Action: "s3:PutObject", Resource: "*", Effect: "Deny", HasCondition: true},
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		action      string
+		resource    string
+		decision    string
+		conditional bool
+		allows      int
+		denies      int
+	}{
+		{
+			name:     "allowed",
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::reports/2024/q1.csv",
+			decision: DecisionAllowed,
+			allows:   1,
+		},
+		{
+			name:     "explicit deny wins over allow",
+			action:   "s3:DeleteObject",
+			resource: "arn:aws:s3:::reports/2024/q1.csv",
+			decision: DecisionExplicitDeny,
+			allows:   1,
+			denies:   1,
+		},
+		{
+			name:     "nothing allows it",
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::payroll/2024.csv",
+			decision: DecisionImplicitDeny,
+		},
+		{
+			name:        "conditional deny",
+			action:      "s3:PutObject",
+			resource:    "arn:aws:s3:::reports/2024/q1.csv",
+			decision:    DecisionAllowed,
+			conditional: true,
+			allows:      1,
+			denies:      1,
+		},
+		{
+			name:        "conditional allow",
+			action:      "kms:Decrypt",
+			resource:    "arn:aws:kms:us-east-1:123456789012:key/abc",
+			decision:    DecisionAllowed,
+			conditional: true,
+			allows:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Evaluate(policies, tt.action, tt.resource)
+			assert.Equal(t, tt.decision, result.Decision)
+			assert.Equal(t, tt.conditional, result.Conditional)
+			assert.Len(t, result.Allows, tt.allows)
+			assert.Len(t, result.Denies, tt.denies)
+		})
+	}
+}
+
+func TestEvaluateBounded(t *testing.T) {
+	policies := []types.Policy{
+		{
+			Name: "s3-access",
+			Permissions: []types.PermissionDisplay{
+				{Action: "s3:*", Resource: "*", Effect: "Allow"},
+			},
+		},
+	}
+	boundary := &types.Policy{
+		Name: "boundary",
+		Permissions: []types.PermissionDisplay{
+			{Action: "s3:Get*", Resource: "*", Effect: "Allow"},
+			{Action: "s3:Put*", Resource: "*", Effect: "Allow", HasCondition: true},
+			{Action: "s3:GetBucketPolicy", Resource: "*", Effect: "Deny"},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		boundary        *types.Policy
+		action          string
+		decision        string
+		conditional     bool
+		outsideBoundary bool
+	}{
+		{
+			name:     "no boundary",
+			action:   "s3:DeleteObject",
+			decision: DecisionAllowed,
+		},
+		{
+			name:     "inside boundary",
+			boundary: boundary,
+			action:   "s3:GetObject",
+			decision: DecisionAllowed,
+		},
+		{
+			name:            "outside boundary",
+			boundary:        boundary,
+			action:          "s3:DeleteObject",
+			decision:        DecisionImplicitDeny,
+			outsideBoundary: true,
+		},
+		{
+			name:     "denied by boundary",
+			boundary: boundary,
+			action:   "s3:GetBucketPolicy",
+			decision: DecisionExplicitDeny,
+		},
+		{
+			name:        "conditional boundary",
+			boundary:    boundary,
+			action:      "s3:PutObject",
+			decision:    DecisionAllowed,
+			conditional: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EvaluateBounded(policies, tt.boundary, tt.action, "arn:aws:s3:::reports/a")
+			assert.Equal(t, tt.decision, result.Decision)
+			assert.Equal(t, tt.conditional, result.Conditional)
+			assert.Equal(t, tt.outsideBoundary, result.OutsideBoundary)
+			assert.Len(t, result.Allows, 1)
+		})
+	}
+}
+
+func TestEvaluate_NotActionNotResource(t *testing.T) {
+	policies := []types.Policy{
+		{
+			Name: "power-user",
+			Permissions: []types.PermissionDisplay{
+				{Action: "iam:*", Resource: "*", Effect: "Allow", NotAction: true},
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::payroll/*", Effect: "Deny", NotResource: true},
+			},
+		},
+	}
+
+	assert.Equal(t, DecisionAllowed, Evaluate(policies, "ec2:RunInstances", "*").Decision)
+	assert.Equal(t, DecisionImplicitDeny, Evaluate(policies, "iam:CreateUser", "*").Decision)
+	assert.Equal(t, DecisionAllowed, Evaluate(policies, "s3:GetObject", "arn:aws:s3:::payroll/2024.csv").Decision)
+	assert.Equal(t, DecisionExplicitDeny, Evaluate(policies, "s3:GetObject", "arn:aws:s3:::reports/2024.csv").Decision)
+}
+
+func TestCovers(t *testing.T) {
+	tests := []struct {
+		name     string
+		outer    types.PermissionDisplay
+		inner    types.PermissionDisplay
+		expected bool
+	}{
+		{
+			name:     "wildcard covers action",
+			outer:    types.PermissionDisplay{Action: "s3:*", Resource: "*"},
+			inner:    types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a"},
+			expected: true,
+		},
+		{
+			name:     "narrow resource does not cover",
+			outer:    types.PermissionDisplay{Action: "s3:*", Resource: "arn:aws:s3:::reports/*"},
+			inner:    types.PermissionDisplay{Action: "s3:GetObject", Resource: "*"},
+			expected: false,
+		},
+		{
+			name:     "full wildcard covers NotAction",
+			outer:    types.PermissionDisplay{Action: "*", Resource: "*"},
+			inner:    types.PermissionDisplay{Action: "iam:*", Resource: "*", NotAction: true},
+			expected: true,
+		},
+		{
+			name:     "NotAction covers disjoint action",
+			outer:    types.PermissionDisplay{Action: "iam:*", Resource: "*", NotAction: true},
+			inner:    types.PermissionDisplay{Action: "s3:GetObject", Resource: "*"},
+			expected: true,
+		},
+		{
+			name:     "NotAction does not cover excluded action",
+			outer:    types.PermissionDisplay{Action: "iam:*", Resource: "*", NotAction: true},
+			inner:    types.PermissionDisplay{Action: "iam:PassRole", Resource: "*"},
+			expected: false,
+		},
+		{
+			name:     "excluding less covers excluding more",
+			outer:    types.PermissionDisplay{Action: "iam:PassRole", Resource: "*", NotAction: true},
+			inner:    types.PermissionDisplay{Action: "iam:*", Resource: "*", NotAction: true},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Covers(tt.outer, tt.inner))
+		})
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	policies := []types.Policy{
+		{
+			Name: "app",
+			Permissions: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+				{Action: "s3:DeleteObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+				{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, IsHighRisk: true},
+				{Action: "ec2:TerminateInstances", Resource: "*", Effect: "Allow", IsHighRisk: true},
+			},
+		},
+		{
+			Name: "guardrails",
+			Permissions: []types.PermissionDisplay{
+				{Action: "s3:Delete*", Resource: "*", Effect: "Deny", IsBroad: true},
+				{Action: "ec2:TerminateInstances", Resource: "*", Effect: "Deny", HasCondition: true},
+			},
+		},
+	}
+
+	annotated := Annotate(policies)
+	assert.Len(t, annotated, 2)
+
+	app := annotated[0].Permissions
+	assert.Empty(t, app[0].Denial)
+	assert.Equal(t, types.DenialFull, app[1].Denial)
+	assert.Equal(t, "guardrails", app[1].DeniedBy)
+	assert.Equal(t, types.DenialPartial, app[2].Denial)
+	assert.Equal(t, types.DenialPartial, app[3].Denial)
+
+	// Deny statements themselves are left alone
+	assert.Equal(t, policies[1].Permissions, annotated[1].Permissions)
+
+	// The input is not modified
+	assert.Empty(t, policies[0].Permissions[1].Denial)
+
+	// A conditional Deny applies fully once its conditions are known to hold,
+	// and not at all when they are known to fail
+	policies[1].Permissions[1].ConditionResult = types.ConditionsHold
+	assert.Equal(t, types.DenialFull, Annotate(policies)[0].Permissions[3].Denial)
+	policies[1].Permissions[1].ConditionResult = types.ConditionsFail
+	assert.Empty(t, Annotate(policies)[0].Permissions[3].Denial)
+}
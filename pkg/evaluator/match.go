@@ -0,0 +1,91 @@
+package evaluator
+
+import "strings"
+
+// MatchAction reports whether an action matches an IAM action pattern.
+// Action names are case-insensitive.
+func MatchAction(pattern, action string) bool {
+	return matchPattern(strings.ToLower(pattern), strings.ToLower(action))
+}
+
+// MatchResource reports whether a resource ARN matches an IAM resource
+// pattern. ARNs are case-sensitive.
+func MatchResource(pattern, resource string) bool {
+	return matchPattern(pattern, resource)
+}
+
+// OverlapAction reports whether two action patterns match at least one
+// common action
+func OverlapAction(a, b string) bool {
+	return overlap(strings.ToLower(a), strings.ToLower(b))
+}
+
+// OverlapResource reports whether two resource patterns match at least one
+// common resource
+func OverlapResource(a, b string) bool {
+	return overlap(a, b)
+}
+
+// matchPattern reports whether value matches pattern, where * matches any
+// sequence of characters and ? any single character
+func matchPattern(pattern, value string) bool {
+	// Iterative glob matching, backtracking to the last * on a mismatch
+	p, v := 0, 0
+	star, match := -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			star = p
+			match = v
+			p++
+		case star != -1:
+			p = star + 1
+			match++
+			v = match
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
+
+// overlap reports whether some string matches both patterns
+func overlap(a, b string) bool {
+	memo := make(map[[2]int]bool)
+	seen := make(map[[2]int]bool)
+
+	var walk func(i, j int) bool
+	walk = func(i, j int) bool {
+		key := [2]int{i, j}
+		if seen[key] {
+			return memo[key]
+		}
+		seen[key] = true
+
+		var result bool
+		switch {
+		case i == len(a) && j == len(b):
+			result = true
+		case i < len(a) && a[i] == '*':
+			// The * matches nothing, or swallows the next character of b
+			result = walk(i+1, j) || (j < len(b) && walk(i, j+1))
+		case j < len(b) && b[j] == '*':
+			result = walk(i, j+1) || (i < len(a) && walk(i+1, j))
+		case i < len(a) && j < len(b):
+			result = (a[i] == b[j] || a[i] == '?' || b[j] == '?') && walk(i+1, j+1)
+		}
+
+		memo[key] = result
+		return result
+	}
+
+	return walk(0, 0)
+}
@@ -0,0 +1,71 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		action   string
+		expected bool
+	}{
+		{name: "exact", pattern: "s3:GetObject", action: "s3:GetObject", expected: true},
+		{name: "service wildcard", pattern: "s3:*", action: "s3:GetObject", expected: true},
+		{name: "prefix wildcard", pattern: "s3:Get*", action: "s3:PutObject", expected: false},
+		{name: "single character", pattern: "s3:GetObjec?", action: "s3:GetObject", expected: true},
+		{name: "case insensitive", pattern: "S3:getobject", action: "s3:GetObject", expected: true},
+		{name: "full wildcard", pattern: "*", action: "iam:PassRole", expected: true},
+		{name: "other service", pattern: "s3:*", action: "s3-object-lambda:GetObject", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MatchAction(tt.pattern, tt.action))
+		})
+	}
+}
+
+func TestMatchResource(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		resource string
+		expected bool
+	}{
+		{name: "wildcard in the middle", pattern: "arn:aws:s3:::*/logs/*", resource: "arn:aws:s3:::bucket/logs/app", expected: true},
+		{name: "case sensitive", pattern: "arn:aws:s3:::Reports/*", resource: "arn:aws:s3:::reports/a", expected: false},
+		{name: "bucket is not an object", pattern: "arn:aws:s3:::reports/*", resource: "arn:aws:s3:::reports", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, MatchResource(tt.pattern, tt.resource))
+		})
+	}
+}
+
+func TestOverlap(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected bool
+	}{
+		{name: "prefix and suffix wildcards", a: "s3:Get*", b: "s3:*Object", expected: true},
+		{name: "disjoint prefixes", a: "s3:Get*", b: "s3:Put*", expected: false},
+		{name: "one covers the other", a: "s3:*", b: "s3:DeleteObject", expected: true},
+		{name: "literals differ", a: "s3:GetObject", b: "s3:PutObject", expected: false},
+		{name: "both wildcards", a: "*", b: "*", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, OverlapAction(tt.a, tt.b))
+			assert.Equal(t, tt.expected, OverlapAction(tt.b, tt.a))
+		})
+	}
+}
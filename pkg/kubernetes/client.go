@@ -2,11 +2,16 @@ package kubernetes
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/berkguzel/pperm/pkg/analyzer"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -16,9 +21,24 @@ import (
 // KubernetesClient defines the interface for our Kubernetes operations
 type KubernetesClient interface {
 	GetPod(ctx context.Context, name, namespace string) (analyzer.Pod, error)
+	ListPods(ctx context.Context, namespace string, selector analyzer.PodSelector) ([]analyzer.Pod, error)
+	ListNamespaces(ctx context.Context) ([]string, error)
+	GetWorkloadPodTemplate(ctx context.Context, kind, name, namespace string) (analyzer.Pod, error)
 	GetServiceAccountIAMRole(ctx context.Context, namespace, name string) (string, error)
+	GetPodAnnotationIAMRole(ctx context.Context, namespace string, annotations map[string]string) (string, error)
+	GetNodeInstanceID(ctx context.Context, nodeName string) (string, error)
 }
 
+// Annotations used by kube2iam and kiam to assign roles to pods and to
+// restrict the roles pods of a namespace may assume: kiam reads a regex from
+// the permitted annotation, and kube2iam, when run with namespace
+// restrictions, a JSON list of roles from the allowed-roles annotation
+const (
+	podRoleAnnotation               = "iam.amazonaws.com/role"
+	namespacePermittedAnnotation    = "iam.amazonaws.com/permitted"
+	namespaceAllowedRolesAnnotation = "iam.amazonaws.com/allowed-roles"
+)
+
 type Client struct {
 	clientset kubernetes.Interface
 }
@@ -51,13 +71,110 @@ func (c *Client) GetPod(ctx context.Context, name, namespace string) (analyzer.P
 		return analyzer.Pod{}, err
 	}
 
+	return toAnalyzerPod(pod), nil
+}
+
+func (c *Client) ListPods(ctx context.Context, namespace string, selector analyzer.PodSelector) ([]analyzer.Pod, error) {
+	// This makes API call to: GET /api/v1/namespaces/{namespace}/pods?labelSelector=...&fieldSelector=...
+	list, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.LabelSelector,
+		FieldSelector: selector.FieldSelector,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]analyzer.Pod, 0, len(list.Items))
+	for i := range list.Items {
+		pods = append(pods, toAnalyzerPod(&list.Items[i]))
+	}
+
+	return pods, nil
+}
+
+func (c *Client) ListNamespaces(ctx context.Context) ([]string, error) {
+	// This makes API call to: GET /api/v1/namespaces
+	list, err := c.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		namespaces = append(namespaces, ns.Name)
+	}
+
+	return namespaces, nil
+}
+
+// GetWorkloadPodTemplate returns the workload's pod template, which is
+// available even when the workload has no running pods
+func (c *Client) GetWorkloadPodTemplate(ctx context.Context, kind, name, namespace string) (analyzer.Pod, error) {
+	var template corev1.PodTemplateSpec
+
+	switch kind {
+	case "Deployment":
+		// This makes API call to: GET /apis/apps/v1/namespaces/{namespace}/deployments/{name}
+		deploy, err := c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return analyzer.Pod{}, err
+		}
+		template = deploy.Spec.Template
+	case "StatefulSet":
+		// This makes API call to: GET /apis/apps/v1/namespaces/{namespace}/statefulsets/{name}
+		sts, err := c.clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return analyzer.Pod{}, err
+		}
+		template = sts.Spec.Template
+	case "DaemonSet":
+		// This makes API call to: GET /apis/apps/v1/namespaces/{namespace}/daemonsets/{name}
+		ds, err := c.clientset.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return analyzer.Pod{}, err
+		}
+		template = ds.Spec.Template
+	case "Job":
+		// This makes API call to: GET /apis/batch/v1/namespaces/{namespace}/jobs/{name}
+		job, err := c.clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return analyzer.Pod{}, err
+		}
+		template = job.Spec.Template
+	case "CronJob":
+		// This makes API call to: GET /apis/batch/v1/namespaces/{namespace}/cronjobs/{name}
+		cronJob, err := c.clientset.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return analyzer.Pod{}, err
+		}
+		template = cronJob.Spec.JobTemplate.Spec.Template
+	default:
+		return analyzer.Pod{}, fmt.Errorf("unsupported workload kind %q", kind)
+	}
+
 	return analyzer.Pod{
+		Namespace:   namespace,
+		Annotations: template.Annotations,
 		Spec: analyzer.PodSpec{
-			ServiceAccountName: pod.Spec.ServiceAccountName,
+			ServiceAccountName: template.Spec.ServiceAccountName,
 		},
 	}, nil
 }
 
+func toAnalyzerPod(pod *corev1.Pod) analyzer.Pod {
+	return analyzer.Pod{
+		Name:        pod.Name,
+		Namespace:   pod.Namespace,
+		Annotations: pod.Annotations,
+		Spec: analyzer.PodSpec{
+			ServiceAccountName: pod.Spec.ServiceAccountName,
+			NodeName:           pod.Spec.NodeName,
+		},
+	}
+}
+
+// GetServiceAccountIAMRole returns the IRSA role annotated on a service
+// account, or nothing when it has no role annotation
 func (c *Client) GetServiceAccountIAMRole(ctx context.Context, namespace, saName string) (string, error) {
 	// This makes API call to: GET /api/v1/namespaces/{namespace}/serviceaccounts/{name}
 	sa, err := c.clientset.CoreV1().ServiceAccounts(namespace).Get(ctx, saName, metav1.GetOptions{})
@@ -66,10 +183,95 @@ func (c *Client) GetServiceAccountIAMRole(ctx context.Context, namespace, saName
 	}
 
 	// Look for the IAM role annotation
-	roleARN, exists := sa.Annotations["eks.amazonaws.com/role-arn"]
-	if !exists {
-		return "", fmt.Errorf("no IAM role annotation found on service account")
+	return sa.Annotations["eks.amazonaws.com/role-arn"], nil
+}
+
+// GetNodeInstanceID returns the EC2 instance ID backing a node, taken from
+// its provider ID which has the form aws:///<availability-zone>/<instance-id>,
+// or nothing for nodes that are not EC2 instances, such as Fargate nodes or
+// nodes of other providers
+func (c *Client) GetNodeInstanceID(ctx context.Context, nodeName string) (string, error) {
+	// This makes API call to: GET /api/v1/nodes/{name}
+	node, err := c.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+
+	providerID := node.Spec.ProviderID
+	if !strings.HasPrefix(providerID, "aws://") {
+		return "", nil
+	}
+
+	instanceID := providerID[strings.LastIndex(providerID, "/")+1:]
+	if !strings.HasPrefix(instanceID, "i-") {
+		return "", nil
+	}
+
+	return instanceID, nil
+}
+
+// GetPodAnnotationIAMRole returns the role a pod requests through the
+// kube2iam/kiam role annotation. Which of the two agents serves it cannot be
+// told from the cluster, so the role must pass the restrictions either agent
+// would apply in the pod's namespace, and is refused otherwise.
+func (c *Client) GetPodAnnotationIAMRole(ctx context.Context, namespace string, annotations map[string]string) (string, error) {
+	role := annotations[podRoleAnnotation]
+	if role == "" {
+		return "", nil
+	}
+
+	// This makes API call to: GET /api/v1/namespaces/{namespace}
+	ns, err := c.clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return "", err
 	}
 
-	return roleARN, nil
+	if permitted, exists := ns.Annotations[namespacePermittedAnnotation]; exists {
+		re, err := regexp.Compile(permitted)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s annotation on namespace %s: %v", namespacePermittedAnnotation, namespace, err)
+		}
+		if !re.MatchString(role) {
+			return "", fmt.Errorf("role %s is not permitted in namespace %s (%s: %q)",
+				role, namespace, namespacePermittedAnnotation, permitted)
+		}
+	}
+
+	if allowed, exists := ns.Annotations[namespaceAllowedRolesAnnotation]; exists {
+		var roles []string
+		if err := json.Unmarshal([]byte(allowed), &roles); err != nil {
+			return "", fmt.Errorf("invalid %s annotation on namespace %s: %v", namespaceAllowedRolesAnnotation, namespace, err)
+		}
+		if !roleAllowed(role, roles) {
+			return "", fmt.Errorf("role %s is not permitted in namespace %s (%s: %s)",
+				role, namespace, namespaceAllowedRolesAnnotation, allowed)
+		}
+	}
+
+	return role, nil
+}
+
+// roleAllowed reports whether a role is in a kube2iam allowed-roles list.
+// Entries may be role names or ARNs, and glob patterns when kube2iam runs
+// with the glob restriction format.
+func roleAllowed(role string, allowed []string) bool {
+	for _, entry := range allowed {
+		if matchRole(entry, role) || matchRole(roleName(entry), roleName(role)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRole(pattern, role string) bool {
+	matched, err := path.Match(pattern, role)
+	return pattern == role || (err == nil && matched)
+}
+
+// roleName is the name of a role given by name or ARN
+func roleName(role string) string {
+	if !strings.HasPrefix(role, "arn:") {
+		return role
+	}
+	return role[strings.LastIndex(role, "/")+1:]
 }
@@ -8,6 +8,11 @@ import (
 	"github.com/berkguzel/pperm/pkg/analyzer"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 // mockKubernetesClient implements KubernetesClient for testing
@@ -20,6 +25,31 @@ func (m *mockKubernetesClient) GetPod(ctx context.Context, name, namespace strin
 	return args.Get(0).(analyzer.Pod), args.Error(1)
 }
 
+func (m *mockKubernetesClient) ListPods(ctx context.Context, namespace string, selector analyzer.PodSelector) ([]analyzer.Pod, error) {
+	args := m.Called(ctx, namespace, selector)
+	return args.Get(0).([]analyzer.Pod), args.Error(1)
+}
+
+func (m *mockKubernetesClient) ListNamespaces(ctx context.Context) ([]string, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *mockKubernetesClient) GetWorkloadPodTemplate(ctx context.Context, kind, name, namespace string) (analyzer.Pod, error) {
+	args := m.Called(ctx, kind, name, namespace)
+	return args.Get(0).(analyzer.Pod), args.Error(1)
+}
+
+func (m *mockKubernetesClient) GetPodAnnotationIAMRole(ctx context.Context, namespace string, annotations map[string]string) (string, error) {
+	args := m.Called(ctx, namespace, annotations)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockKubernetesClient) GetNodeInstanceID(ctx context.Context, nodeName string) (string, error) {
+	args := m.Called(ctx, nodeName)
+	return args.String(0), args.Error(1)
+}
+
 func (m *mockKubernetesClient) GetServiceAccountIAMRole(ctx context.Context, namespace, name string) (string, error) {
 	args := m.Called(ctx, namespace, name)
 	return args.String(0), args.Error(1)
@@ -120,3 +150,233 @@ func TestClient_GetServiceAccountIAMRole(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ListPods(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "payments", Labels: map[string]string{"app": "api"}},
+			Spec:       corev1.PodSpec{ServiceAccountName: "api-sa"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "payments"},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+		},
+	)
+	client := &Client{clientset: clientset}
+
+	pods, err := client.ListPods(context.Background(), "payments", analyzer.PodSelector{})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []analyzer.Pod{
+		{Name: "api", Namespace: "payments", Spec: analyzer.PodSpec{ServiceAccountName: "api-sa"}},
+		{Name: "worker", Namespace: "payments"},
+	}, pods)
+
+	pods, err = client.ListPods(context.Background(), "payments", analyzer.PodSelector{LabelSelector: "app=api"})
+	assert.NoError(t, err)
+	assert.Equal(t, []analyzer.Pod{
+		{Name: "api", Namespace: "payments", Spec: analyzer.PodSpec{ServiceAccountName: "api-sa"}},
+	}, pods)
+}
+
+func TestClient_ListNamespaces(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+	)
+	client := &Client{clientset: clientset}
+
+	namespaces, err := client.ListNamespaces(context.Background())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"default", "payments"}, namespaces)
+}
+
+func TestClient_GetWorkloadPodTemplate(t *testing.T) {
+	template := func(saName string) corev1.PodTemplateSpec {
+		return corev1.PodTemplateSpec{Spec: corev1.PodSpec{ServiceAccountName: saName}}
+	}
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "default"}
+	}
+
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: meta("api"), Spec: appsv1.DeploymentSpec{Template: template("api-sa")}},
+		&appsv1.StatefulSet{ObjectMeta: meta("db"), Spec: appsv1.StatefulSetSpec{Template: template("db-sa")}},
+		&appsv1.DaemonSet{ObjectMeta: meta("agent"), Spec: appsv1.DaemonSetSpec{Template: template("agent-sa")}},
+		&batchv1.Job{ObjectMeta: meta("migrate"), Spec: batchv1.JobSpec{Template: template("migrate-sa")}},
+		&batchv1.CronJob{ObjectMeta: meta("nightly-export"), Spec: batchv1.CronJobSpec{
+			JobTemplate: batchv1.JobTemplateSpec{Spec: batchv1.JobSpec{Template: template("export-sa")}},
+		}},
+	)
+	client := &Client{clientset: clientset}
+
+	tests := []struct {
+		name          string
+		kind          string
+		workload      string
+		expected      string
+		expectedError string
+	}{
+		{name: "deployment", kind: "Deployment", workload: "api", expected: "api-sa"},
+		{name: "statefulset", kind: "StatefulSet", workload: "db", expected: "db-sa"},
+		{name: "daemonset", kind: "DaemonSet", workload: "agent", expected: "agent-sa"},
+		{name: "job", kind: "Job", workload: "migrate", expected: "migrate-sa"},
+		{name: "cronjob", kind: "CronJob", workload: "nightly-export", expected: "export-sa"},
+		{name: "missing workload", kind: "Deployment", workload: "missing", expectedError: "not found"},
+		{name: "unsupported kind", kind: "ReplicaSet", workload: "api", expectedError: "unsupported workload kind"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, err := client.GetWorkloadPodTemplate(context.Background(), tt.kind, tt.workload, "default")
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, template.Spec.ServiceAccountName)
+			}
+		})
+	}
+}
+
+func TestClient_GetPodAnnotationIAMRole(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "open"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "kiam",
+			Annotations: map[string]string{"iam.amazonaws.com/permitted": "reports-.*"},
+		}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "kube2iam",
+			Annotations: map[string]string{"iam.amazonaws.com/allowed-roles": `["arn:aws:iam::123456789012:role/app", "reports-*"]`},
+		}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "broken",
+			Annotations: map[string]string{"iam.amazonaws.com/permitted": "reports-("},
+		}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "broken-list",
+			Annotations: map[string]string{"iam.amazonaws.com/allowed-roles": "app"},
+		}},
+	)
+	client := &Client{clientset: clientset}
+
+	tests := []struct {
+		name          string
+		namespace     string
+		annotations   map[string]string
+		expectedRole  string
+		expectedError string
+	}{
+		{
+			name:      "no role annotation",
+			namespace: "open",
+		},
+		{
+			name:         "unrestricted namespace",
+			namespace:    "open",
+			annotations:  map[string]string{"iam.amazonaws.com/role": "arn:aws:iam::123456789012:role/app"},
+			expectedRole: "arn:aws:iam::123456789012:role/app",
+		},
+		{
+			name:         "permitted role",
+			namespace:    "kiam",
+			annotations:  map[string]string{"iam.amazonaws.com/role": "reports-reader"},
+			expectedRole: "reports-reader",
+		},
+		{
+			name:          "role not permitted",
+			namespace:     "kiam",
+			annotations:   map[string]string{"iam.amazonaws.com/role": "admin"},
+			expectedError: "role admin is not permitted in namespace kiam",
+		},
+		{
+			name:         "allowed role named without its ARN",
+			namespace:    "kube2iam",
+			annotations:  map[string]string{"iam.amazonaws.com/role": "app"},
+			expectedRole: "app",
+		},
+		{
+			name:         "allowed role matching a glob",
+			namespace:    "kube2iam",
+			annotations:  map[string]string{"iam.amazonaws.com/role": "arn:aws:iam::123456789012:role/reports-writer"},
+			expectedRole: "arn:aws:iam::123456789012:role/reports-writer",
+		},
+		{
+			name:          "role not allowed",
+			namespace:     "kube2iam",
+			annotations:   map[string]string{"iam.amazonaws.com/role": "admin"},
+			expectedError: "role admin is not permitted in namespace kube2iam",
+		},
+		{
+			name:          "invalid permitted regex",
+			namespace:     "broken",
+			annotations:   map[string]string{"iam.amazonaws.com/role": "reports-reader"},
+			expectedError: "invalid iam.amazonaws.com/permitted annotation",
+		},
+		{
+			name:          "invalid allowed roles",
+			namespace:     "broken-list",
+			annotations:   map[string]string{"iam.amazonaws.com/role": "app"},
+			expectedError: "invalid iam.amazonaws.com/allowed-roles annotation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, err := client.GetPodAnnotationIAMRole(context.Background(), tt.namespace, tt.annotations)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRole, role)
+			}
+		})
+	}
+}
+
+func TestClient_GetNodeInstanceID(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "ip-10-0-1-23.ec2.internal"},
+			Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/i-0123456789abcdef0"},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "kind-worker"},
+			Spec:       corev1.NodeSpec{ProviderID: "kind://docker/kind/kind-worker"},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "fargate-node"},
+			Spec:       corev1.NodeSpec{ProviderID: "aws:///us-east-1a/fargate-ip-10-0-1-24"},
+		},
+	)
+	client := &Client{clientset: clientset}
+
+	tests := []struct {
+		name          string
+		nodeName      string
+		expected      string
+		expectedError string
+	}{
+		{name: "EC2 node", nodeName: "ip-10-0-1-23.ec2.internal", expected: "i-0123456789abcdef0"},
+		{name: "non-AWS node", nodeName: "kind-worker", expected: ""},
+		{name: "Fargate node", nodeName: "fargate-node", expected: ""},
+		{name: "missing node", nodeName: "missing", expectedError: "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instanceID, err := client.GetNodeInstanceID(context.Background(), tt.nodeName)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, instanceID)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// expandActions returns the concrete actions a permission's wildcard action
+// grants. ok is false for exact actions, NotAction exclusions and wildcards
+// on services the action catalog does not cover.
+func expandActions(p types.PermissionDisplay) (actions []catalog.Action, ok bool) {
+	if p.NotAction || !strings.ContainsAny(p.Action, "*?") {
+		return nil, false
+	}
+	return catalog.Default().Expand(p.Action)
+}
+
+// actionCell is the ACTION cell of a permission, counting the actions a
+// wildcard expands to, e.g. "s3:Get* → 58 actions"
+func actionCell(p types.PermissionDisplay) string {
+	actions, ok := expandActions(p)
+	if !ok {
+		return p.ActionLabel()
+	}
+	if len(actions) == 1 {
+		return fmt.Sprintf("%s → 1 action", p.ActionLabel())
+	}
+	return fmt.Sprintf("%s → %d actions", p.ActionLabel(), len(actions))
+}
+
+// padCell pads s with spaces to width columns, counting runes rather than
+// bytes so that arrows don't shift the table
+func padCell(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// printExpandedActionRows prints one indented row per concrete action a
+// wildcard permission expands to, below the permission's own row, along with
+// the usage of each action when the USAGE column is shown
+func printExpandedActionRows(perm types.PodPermissions, p types.PermissionDisplay, resourceWidth int, columns podColumns, unusedDays int) {
+	actions, ok := expandActions(p)
+	if !ok {
+		return
+	}
+
+	for _, a := range actions {
+		action := types.PermissionDisplay{Action: a.Name, Effect: p.Effect}
+		fmt.Printf("%s| %-30s |   %s | %-*s | %-5s | %-*s |%s\n",
+			columns.cells(perm),
+			"",
+			padCell(a.Name, 33),
+			resourceWidth,
+			"",
+			"",
+			riskColumnWidth,
+			"",
+			columns.usageCell(usageCell(action, perm.Usage, unusedDays)),
+		)
+	}
+}
+
+// printWildcardActions lists the concrete actions behind each wildcard
+// action of a policy
+func printWildcardActions(policy types.Policy) {
+	seen := make(map[string]bool)
+	for _, p := range policy.Permissions {
+		actions, ok := expandActions(p)
+		if !ok || seen[p.Action] {
+			continue
+		}
+		seen[p.Action] = true
+
+		fmt.Printf("\n%s:\n", actionCell(p))
+		for _, a := range actions {
+			fmt.Printf("  %-50s %s\n", a.Name, a.AccessLevel)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionCell(t *testing.T) {
+	tests := []struct {
+		name     string
+		perm     types.PermissionDisplay
+		expected string
+	}{
+		{
+			name:     "exact action",
+			perm:     types.PermissionDisplay{Action: "s3:GetObject"},
+			expected: "s3:GetObject",
+		},
+		{
+			name:     "wildcard action",
+			perm:     types.PermissionDisplay{Action: "sqs:*Message"},
+			expected: "sqs:*Message → 3 actions",
+		},
+		{
+			name:     "wildcard matching one action",
+			perm:     types.PermissionDisplay{Action: "sqs:PurgeQ*"},
+			expected: "sqs:PurgeQ* → 1 action",
+		},
+		{
+			name:     "service missing from catalog",
+			perm:     types.PermissionDisplay{Action: "athena:Get*"},
+			expected: "athena:Get*",
+		},
+		{
+			name:     "NotAction",
+			perm:     types.PermissionDisplay{Action: "iam:*", NotAction: true},
+			expected: "NOT iam:*",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, actionCell(tt.perm))
+		})
+	}
+}
+
+func TestPadCell(t *testing.T) {
+	assert.Equal(t, "s3:* → 2 actions  ", padCell("s3:* → 2 actions", 18))
+	assert.Equal(t, "too long", padCell("too long", 4))
+}
@@ -0,0 +1,229 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// PrintCanI answers whether each analyzed pod may perform the requested
+// actions on the requested resources, naming the statements that decide it.
+// When the AWS policy simulator was consulted its answers are compared with
+// pperm's. It reports whether every pod may perform every action.
+func PrintCanI(perms []types.PodPermissions, opts *options.Options) bool {
+	for _, action := range opts.Actions {
+		warnUnknownAction(action)
+	}
+
+	if len(perms) == 0 {
+		fmt.Println("no - no pods found")
+		return false
+	}
+
+	reqCtx := evaluator.NewContext(opts.Context)
+
+	// Label every answer when there is more than one request per pod
+	several := len(opts.Actions)*len(opts.Resources) > 1
+
+	allowed := true
+	disagreements := 0
+	for i, perm := range perms {
+		if len(perms) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s/%s:\n", perm.Namespace, displayName(perm))
+		}
+
+		if perm.IAMRole == "" {
+			fmt.Println("no - no IAM role")
+			allowed = false
+			continue
+		}
+
+		first := true
+		for _, action := range opts.Actions {
+			for _, resource := range opts.Resources {
+				if several {
+					if !first {
+						fmt.Println()
+					}
+					fmt.Printf("%s on %s: ", action, resource)
+				}
+				first = false
+
+				result := evaluator.EvaluateBounded(perm.Policies, perm.PermissionsBoundary, action, resource)
+				printCanIResult(result, perm, action, resource, reqCtx)
+				if result.Decision != evaluator.DecisionAllowed {
+					allowed = false
+				}
+
+				if opts.Simulate && !compareSimulation(result, perm.Simulation, action, resource) {
+					disagreements++
+				}
+			}
+		}
+	}
+
+	if opts.Simulate {
+		fmt.Println()
+		if disagreements == 0 {
+			fmt.Println("The AWS policy simulator agrees with every answer")
+		} else {
+			fmt.Printf("%s The AWS policy simulator disagrees with %d answer(s)\n", warning, disagreements)
+		}
+	}
+
+	return allowed
+}
+
+// printCanIResult prints the answer for a single request followed by the
+// statements behind it. With a request context, each condition is marked
+// with whether it holds.
+func printCanIResult(result evaluator.Result, perm types.PodPermissions, action, resource string, reqCtx evaluator.Context) {
+	switch {
+	case result.Decision == evaluator.DecisionAllowed && result.Conditional:
+		fmt.Println("yes, if the conditions of the statements below hold")
+	case result.Decision == evaluator.DecisionAllowed:
+		fmt.Println("yes")
+	case result.Decision == evaluator.DecisionExplicitDeny:
+		fmt.Println("no - explicitly denied")
+	case result.OutsideBoundary:
+		fmt.Printf("no - outside permissions boundary %s\n", perm.PermissionsBoundary.Name)
+	case unmetAllow(result.Unmet):
+		fmt.Println("no - the conditions of the statements allowing it do not hold in the given context")
+	default:
+		fmt.Printf("no - implicitly denied: no statement allows %s on %s\n", action, resource)
+	}
+
+	if len(result.Denies) > 0 {
+		fmt.Println("Denied by:")
+		printMatches(result.Denies, reqCtx)
+	}
+	if len(result.Allows) > 0 {
+		if result.Decision == evaluator.DecisionAllowed {
+			fmt.Println("Allowed by:")
+		} else {
+			fmt.Println("Allowed, but overridden, by:")
+		}
+		printMatches(result.Allows, reqCtx)
+	}
+	if len(result.Unmet) > 0 {
+		fmt.Println("Not in effect in the given context:")
+		printMatches(result.Unmet, reqCtx)
+	}
+}
+
+// unmetAllow reports whether any of the statements not in effect is an Allow
+func unmetAllow(unmet []evaluator.Match) bool {
+	for _, m := range unmet {
+		if m.Permission.Effect == "Allow" {
+			return true
+		}
+	}
+	return false
+}
+
+func printMatches(matches []evaluator.Match, reqCtx evaluator.Context) {
+	for _, m := range matches {
+		p := m.Permission
+		fmt.Printf("  %s: %s %s on %s\n", m.Policy, p.Effect, p.ActionLabel(), p.ResourceLabel())
+		for i, c := range p.Conditions {
+			keyword := "and"
+			if i == 0 {
+				keyword = "if"
+			}
+			fmt.Printf("    %s %s%s\n", keyword, c, conditionVerdict(c, reqCtx))
+		}
+	}
+}
+
+// conditionVerdict tells whether a condition holds in the request context,
+// or returns "" without one
+func conditionVerdict(c types.Condition, reqCtx evaluator.Context) string {
+	if reqCtx == nil {
+		return ""
+	}
+	holds, known := evaluator.EvaluateCondition(c, reqCtx)
+	switch {
+	case !known:
+		return " (cannot be evaluated)"
+	case holds:
+		return " (holds)"
+	default:
+		return " (does not hold)"
+	}
+}
+
+// compareSimulation prints the AWS policy simulator's answer to a request
+// and reports whether it matches the local one
+func compareSimulation(result evaluator.Result, simulation []types.SimulationResult, action, resource string) bool {
+	sim, ok := findSimulation(simulation, action, resource)
+	if !ok {
+		fmt.Println("AWS policy simulator: no answer")
+		return false
+	}
+
+	fmt.Printf("AWS policy simulator: %s", sim.Decision)
+	if len(sim.MatchedPolicies) > 0 {
+		fmt.Printf(" (%s)", strings.Join(sim.MatchedPolicies, ", "))
+	}
+	fmt.Println()
+
+	if sim.Decision == result.Decision {
+		return true
+	}
+
+	fmt.Printf("%s pperm found %s %s, the AWS policy simulator %s\n", warning, action, result.Decision, sim.Decision)
+	switch {
+	case len(sim.MissingContext) > 0:
+		fmt.Printf("  the simulator had no value for %s\n", strings.Join(sim.MissingContext, ", "))
+	case result.Conditional:
+		fmt.Println("  the answer depends on conditions pperm cannot evaluate")
+	default:
+		fmt.Println("  resource-based policies, SCPs or session policies may be involved")
+	}
+	return false
+}
+
+// findSimulation looks up the simulator's answer to a request. The simulator
+// reports requests on * without naming a resource.
+func findSimulation(simulation []types.SimulationResult, action, resource string) (types.SimulationResult, bool) {
+	for _, sim := range simulation {
+		if strings.EqualFold(sim.Action, action) && (sim.Resource == resource || sim.Resource == "") {
+			return sim, true
+		}
+	}
+	return types.SimulationResult{}, false
+}
+
+// warnUnknownAction points out likely typos: actions of a service the action
+// catalog covers that the catalog does not list
+func warnUnknownAction(action string) {
+	if strings.ContainsAny(action, "*?") {
+		return
+	}
+	service, _, _ := strings.Cut(action, ":")
+	if _, known := catalog.Default().Expand(service + ":*"); !known {
+		return
+	}
+	if _, ok := catalog.Default().Lookup(action); !ok {
+		fmt.Printf("%s %s is not a known %s action\n", warning, action, service)
+	}
+}
+
+// displayName names the pod, workload or service account that was analyzed
+func displayName(perm types.PodPermissions) string {
+	switch {
+	case perm.PodName != "":
+		return perm.PodName
+	case perm.Workload != "":
+		return perm.Workload
+	default:
+		return "sa/" + perm.ServiceAccount
+	}
+}
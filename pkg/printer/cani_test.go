@@ -0,0 +1,152 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintCanI(t *testing.T) {
+	pod := types.PodPermissions{
+		PodName:   "api",
+		Namespace: "payments",
+		IAMRole:   "arn:aws:iam::123456789012:role/api",
+		Policies: []types.Policy{
+			{
+				Name: "reports",
+				Permissions: []types.PermissionDisplay{
+					{Action: "s3:*", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+					{Action: "s3:Delete*", Resource: "*", Effect: "Deny"},
+				},
+			},
+		},
+	}
+
+	vpcOnly := func(result string) types.PodPermissions {
+		return types.PodPermissions{
+			PodName:   "api",
+			Namespace: "payments",
+			IAMRole:   "arn:aws:iam::123456789012:role/api",
+			Policies: []types.Policy{
+				{
+					Name: "vpc-only",
+					Permissions: []types.PermissionDisplay{
+						{Action: "s3:GetObject", Resource: "*", Effect: "Allow", HasCondition: true, ConditionResult: result, Conditions: []types.Condition{
+							{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}},
+						}},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		perms    []types.PodPermissions
+		action   string
+		resource string
+		context  map[string][]string
+		expected bool
+	}{
+		{
+			name:     "allowed through wildcards",
+			perms:    []types.PodPermissions{pod},
+			action:   "s3:PutObject",
+			resource: "arn:aws:s3:::reports/2024/q1.csv",
+			expected: true,
+		},
+		{
+			name:     "explicitly denied",
+			perms:    []types.PodPermissions{pod},
+			action:   "s3:DeleteObject",
+			resource: "arn:aws:s3:::reports/2024/q1.csv",
+			expected: false,
+		},
+		{
+			name:     "implicitly denied",
+			perms:    []types.PodPermissions{pod},
+			action:   "s3:PutObject",
+			resource: "arn:aws:s3:::payroll/2024.csv",
+			expected: false,
+		},
+		{
+			name:     "no IAM role",
+			perms:    []types.PodPermissions{{PodName: "worker", Namespace: "payments"}},
+			action:   "s3:GetObject",
+			resource: "*",
+			expected: false,
+		},
+		{
+			name:     "conditions hold in the given context",
+			perms:    []types.PodPermissions{vpcOnly(types.ConditionsHold)},
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::reports/a",
+			context:  map[string][]string{"aws:SourceVpc": {"vpc-123"}},
+			expected: true,
+		},
+		{
+			name:     "conditions fail in the given context",
+			perms:    []types.PodPermissions{vpcOnly(types.ConditionsFail)},
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::reports/a",
+			context:  map[string][]string{"aws:SourceVpc": {"vpc-999"}},
+			expected: false,
+		},
+		{
+			name:     "one of several pods denied",
+			perms:    []types.PodPermissions{pod, {PodName: "worker", Namespace: "payments"}},
+			action:   "s3:GetObject",
+			resource: "arn:aws:s3:::reports/a",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &options.Options{Command: options.CommandCanI, Actions: []string{tt.action}, Resources: []string{tt.resource}, Context: tt.context}
+			assert.Equal(t, tt.expected, PrintCanI(tt.perms, opts))
+		})
+	}
+}
+
+func TestCompareSimulation(t *testing.T) {
+	simulation := []types.SimulationResult{
+		{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a", Decision: "allowed"},
+		{Action: "s3:PutObject", Resource: "arn:aws:s3:::reports/a", Decision: "implicitly denied", MissingContext: []string{"aws:SourceIp"}},
+	}
+
+	tests := []struct {
+		name     string
+		result   evaluator.Result
+		action   string
+		expected bool
+	}{
+		{
+			name:     "agreement",
+			result:   evaluator.Result{Decision: evaluator.DecisionAllowed},
+			action:   "s3:GetObject",
+			expected: true,
+		},
+		{
+			name:     "disagreement",
+			result:   evaluator.Result{Decision: evaluator.DecisionAllowed, Conditional: true},
+			action:   "s3:PutObject",
+			expected: false,
+		},
+		{
+			name:     "no simulator answer",
+			result:   evaluator.Result{Decision: evaluator.DecisionImplicitDeny},
+			action:   "s3:DeleteObject",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, compareSimulation(tt.result, simulation, tt.action, "arn:aws:s3:::reports/a"))
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// conditionKeys describes well-known condition keys
+var conditionKeys = map[string]string{
+	"aws:SourceVpce":             "VPC endpoint the request is sent through",
+	"aws:SourceVpc":              "VPC the request is sent from",
+	"aws:SourceIp":               "IP address the request is sent from",
+	"aws:VpcSourceIp":            "IP address the request is sent from within a VPC",
+	"aws:SecureTransport":        "whether the request uses TLS",
+	"aws:MultiFactorAuthPresent": "whether the caller signed in with MFA",
+	"aws:PrincipalOrgID":         "AWS Organization of the caller",
+	"aws:PrincipalAccount":       "account of the caller",
+	"aws:PrincipalArn":           "ARN of the caller",
+	"aws:SourceAccount":          "account of the resource acting on the caller's behalf",
+	"aws:SourceArn":              "ARN of the resource acting on the caller's behalf",
+	"aws:ResourceAccount":        "account owning the resource",
+	"aws:RequestedRegion":        "region the request is sent to",
+	"aws:CalledVia":              "services making the request on the caller's behalf",
+	"aws:TagKeys":                "tag keys in the request",
+	"s3:prefix":                  "key prefix of an S3 listing",
+	"kms:ViaService":             "service using the KMS key on the caller's behalf",
+	"iam:PassedToService":        "service the role is passed to",
+}
+
+// conditionKeyPrefixes describes condition keys that embed a name, such as
+// aws:PrincipalTag/team
+var conditionKeyPrefixes = map[string]string{
+	"aws:PrincipalTag/":           "caller's tag %s",
+	"aws:ResourceTag/":            "resource's tag %s",
+	"aws:RequestTag/":             "tag %s in the request",
+	"kms:EncryptionContext:":      "encryption context entry %s",
+	"ec2:ResourceTag/":            "resource's tag %s",
+	"secretsmanager:ResourceTag/": "secret's tag %s",
+}
+
+// explainCondition describes what a condition tests in plain words, or
+// returns "" for keys it knows nothing about
+func explainCondition(c types.Condition) string {
+	description, ok := conditionKeys[c.Key]
+	if !ok {
+		for prefix, format := range conditionKeyPrefixes {
+			if strings.HasPrefix(c.Key, prefix) {
+				description = fmt.Sprintf(format, strings.TrimPrefix(c.Key, prefix))
+				break
+			}
+		}
+	}
+	if description == "" {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(c.Operator, "ForAnyValue:"):
+		description += ", any of which may match"
+	case strings.HasPrefix(c.Operator, "ForAllValues:"):
+		description += ", all of which must match"
+	}
+	if strings.HasSuffix(c.Operator, "IfExists") {
+		description += ", only checked when present"
+	}
+
+	return description
+}
+
+// printConditionRows prints the conditions of a permission as indented rows
+// below it, the first prefixed with "if" and the rest with "and"
+func printConditionRows(perm types.PodPermissions, p types.PermissionDisplay, resourceWidth int, columns podColumns) {
+	for i, c := range p.Conditions {
+		keyword := "and"
+		if i == 0 {
+			keyword = "if"
+		}
+		fmt.Printf("%s| %-30s |   %-33s | %-*s | %-5s | %-*s |%s\n",
+			columns.cells(perm),
+			"",
+			keyword,
+			resourceWidth,
+			c.String(),
+			"",
+			riskColumnWidth,
+			"",
+			columns.usageCell(""),
+		)
+	}
+}
+
+// resourceCellWidth is the width the RESOURCE column needs for a
+// permission, whose condition rows share the column
+func resourceCellWidth(p types.PermissionDisplay) int {
+	width := len(p.ResourceLabel())
+	for _, c := range p.Conditions {
+		if len(c.String()) > width {
+			width = len(c.String())
+		}
+	}
+	return width
+}
+
+// printConditionDetails lists the conditions of every permission of a
+// policy that has any, explaining the keys it knows and whether they hold in
+// the request context
+func printConditionDetails(policy types.Policy) {
+	header := false
+	for _, p := range policy.Permissions {
+		if len(p.Conditions) == 0 {
+			continue
+		}
+		if !header {
+			fmt.Println("\nConditions:")
+			header = true
+		}
+
+		switch p.ConditionResult {
+		case types.ConditionsHold:
+			fmt.Printf("  %s %s on %s when (met in the given context):\n", p.Effect, p.ActionLabel(), p.ResourceLabel())
+		case types.ConditionsFail:
+			fmt.Printf("  %s %s on %s when (not met in the given context):\n", p.Effect, p.ActionLabel(), p.ResourceLabel())
+		default:
+			fmt.Printf("  %s %s on %s when:\n", p.Effect, p.ActionLabel(), p.ResourceLabel())
+		}
+		for _, c := range p.Conditions {
+			if explanation := explainCondition(c); explanation != "" {
+				fmt.Printf("    %s (%s)\n", c, explanation)
+			} else {
+				fmt.Printf("    %s\n", c)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition types.Condition
+		expected  string
+	}{
+		{
+			name:      "well-known key",
+			condition: types.Condition{Operator: "StringEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1"}},
+			expected:  "VPC endpoint the request is sent through",
+		},
+		{
+			name:      "tag key",
+			condition: types.Condition{Operator: "StringEquals", Key: "aws:PrincipalTag/team", Values: []string{"payments"}},
+			expected:  "caller's tag team",
+		},
+		{
+			name:      "set operator",
+			condition: types.Condition{Operator: "ForAllValues:StringEquals", Key: "aws:TagKeys", Values: []string{"team", "env"}},
+			expected:  "tag keys in the request, all of which must match",
+		},
+		{
+			name:      "IfExists operator",
+			condition: types.Condition{Operator: "StringEqualsIfExists", Key: "aws:RequestedRegion", Values: []string{"eu-west-1"}},
+			expected:  "region the request is sent to, only checked when present",
+		},
+		{
+			name:      "unknown key",
+			condition: types.Condition{Operator: "StringEquals", Key: "dynamodb:LeadingKeys", Values: []string{"${aws:userid}"}},
+			expected:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, explainCondition(tt.condition))
+		})
+	}
+}
+
+func TestResourceCellWidth(t *testing.T) {
+	p := types.PermissionDisplay{
+		Action:   "s3:ListBucket",
+		Resource: "arn:aws:s3:::reports",
+		Conditions: []types.Condition{
+			{Operator: "StringLike", Key: "s3:prefix", Values: []string{"home/", "public/*"}},
+		},
+	}
+	assert.Equal(t, len("s3:prefix StringLike [home/, public/*]"), resourceCellWidth(p))
+}
@@ -0,0 +1,29 @@
+package printer
+
+import (
+	"fmt"
+
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// printEscalationPaths lists the privilege-escalation paths of each pod,
+// with the permissions enabling them and the policies holding those
+func printEscalationPaths(perms []types.PodPermissions) {
+	for _, perm := range perms {
+		if len(perm.EscalationPaths) == 0 {
+			continue
+		}
+
+		fmt.Printf("\n%s %s/%s can escalate its privileges:\n", red("🚨"), perm.Namespace, displayName(perm))
+		for _, path := range perm.EscalationPaths {
+			fmt.Printf("  %s", path.Path)
+			if path.Conditional {
+				fmt.Print(" (if the conditions of the statements below hold)")
+			}
+			fmt.Printf("\n    %s\n", path.Description)
+			for _, g := range path.Grants {
+				fmt.Printf("    %s: %s\n", g.Policy, describePermission(g.Permission))
+			}
+		}
+	}
+}
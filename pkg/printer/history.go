@@ -0,0 +1,164 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// historyTimeFormat is how policy version creation dates are shown
+const historyTimeFormat = "2006-01-02 15:04 MST"
+
+// PrintHistory prints how the policy asked for changed over the versions IAM
+// keeps of it: every version, newest first, with the permissions it added
+// and removed compared to the version before it, or only the changes between
+// the two versions given on the command line.
+func PrintHistory(perms []types.PodPermissions, opts *options.Options) error {
+	if len(perms) == 0 {
+		fmt.Println("No pods found")
+		return nil
+	}
+
+	for i, perm := range perms {
+		if len(perms) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s/%s:\n", perm.Namespace, displayName(perm))
+		}
+
+		if perm.IAMRole == "" {
+			fmt.Println("no IAM role")
+			continue
+		}
+
+		fmt.Printf("Policy: %s\n", opts.PolicyName)
+		if len(opts.Versions) == 2 {
+			if err := printVersionChanges(perm.PolicyHistory, opts.Versions[0], opts.Versions[1]); err != nil {
+				return err
+			}
+			continue
+		}
+		printVersions(perm.PolicyHistory)
+	}
+
+	return nil
+}
+
+// printVersions lists the versions of a policy, newest first, each followed
+// by what changed since the version before it. The oldest version IAM still
+// keeps is listed with all of its permissions.
+func printVersions(history []types.PolicyVersion) {
+	for i, v := range history {
+		fmt.Printf("\n%s  %s", v.VersionID, v.CreateDate.UTC().Format(historyTimeFormat))
+		if v.IsDefault {
+			fmt.Print("  (default)")
+		}
+		fmt.Println()
+
+		var previous []types.PermissionDisplay
+		if i+1 < len(history) {
+			previous = history[i+1].Permissions
+		} else {
+			fmt.Println("  oldest version kept by IAM")
+		}
+		printPermissionChanges(previous, v.Permissions)
+	}
+}
+
+// printVersionChanges prints the changes between two versions of a policy
+func printVersionChanges(history []types.PolicyVersion, fromID, toID string) error {
+	from, ok := findVersion(history, fromID)
+	if !ok {
+		return fmt.Errorf("policy has no version %s; IAM keeps %s", fromID, versionIDs(history))
+	}
+	to, ok := findVersion(history, toID)
+	if !ok {
+		return fmt.Errorf("policy has no version %s; IAM keeps %s", toID, versionIDs(history))
+	}
+
+	fmt.Printf("\nChanges from %s (%s) to %s (%s):\n",
+		from.VersionID, from.CreateDate.UTC().Format(historyTimeFormat),
+		to.VersionID, to.CreateDate.UTC().Format(historyTimeFormat))
+	printPermissionChanges(from.Permissions, to.Permissions)
+	return nil
+}
+
+// printPermissionChanges prints the permissions added and removed going from
+// one set of permissions to another
+func printPermissionChanges(from, to []types.PermissionDisplay) {
+	added, removed := diffPermissions(from, to)
+	if len(added) == 0 && len(removed) == 0 {
+		fmt.Println("  no permission changes")
+		return
+	}
+
+	for _, p := range added {
+		fmt.Printf("  %s %s\n", green("+"), describePermission(p))
+	}
+	for _, p := range removed {
+		fmt.Printf("  %s %s\n", red("-"), describePermission(p))
+	}
+}
+
+// diffPermissions returns the permissions only in to, and those only in
+// from. Permissions are the same when their effect, action, resource and
+// conditions are.
+func diffPermissions(from, to []types.PermissionDisplay) (added, removed []types.PermissionDisplay) {
+	fromSet := make(map[string]bool)
+	for _, p := range from {
+		fromSet[describePermission(p)] = true
+	}
+	toSet := make(map[string]bool)
+	for _, p := range to {
+		toSet[describePermission(p)] = true
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range to {
+		if d := describePermission(p); !fromSet[d] && !seen[d] {
+			seen[d] = true
+			added = append(added, p)
+		}
+	}
+	for _, p := range from {
+		if d := describePermission(p); !toSet[d] && !seen[d] {
+			seen[d] = true
+			removed = append(removed, p)
+		}
+	}
+	return added, removed
+}
+
+// describePermission renders a permission as a single line, e.g.
+// "Allow s3:GetObject on arn:aws:s3:::reports/* if aws:SourceVpc StringEquals vpc-123"
+func describePermission(p types.PermissionDisplay) string {
+	line := fmt.Sprintf("%s %s on %s", p.Effect, p.ActionLabel(), p.ResourceLabel())
+	for i, c := range p.Conditions {
+		keyword := "and"
+		if i == 0 {
+			keyword = "if"
+		}
+		line += fmt.Sprintf(" %s %s", keyword, c)
+	}
+	return line
+}
+
+func findVersion(history []types.PolicyVersion, id string) (types.PolicyVersion, bool) {
+	for _, v := range history {
+		if strings.EqualFold(v.VersionID, id) {
+			return v, true
+		}
+	}
+	return types.PolicyVersion{}, false
+}
+
+func versionIDs(history []types.PolicyVersion) string {
+	ids := make([]string, len(history))
+	for i, v := range history {
+		ids[i] = v.VersionID
+	}
+	return strings.Join(ids, ", ")
+}
@@ -0,0 +1,141 @@
+package printer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffPermissions(t *testing.T) {
+	put := types.PermissionDisplay{Action: "s3:PutObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"}
+	del := types.PermissionDisplay{Action: "s3:DeleteObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"}
+	vpcPut := put
+	vpcPut.Conditions = []types.Condition{{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}}}
+	denyDel := del
+	denyDel.Effect = "Deny"
+
+	tests := []struct {
+		name    string
+		from    []types.PermissionDisplay
+		to      []types.PermissionDisplay
+		added   []types.PermissionDisplay
+		removed []types.PermissionDisplay
+	}{
+		{
+			name: "unchanged",
+			from: []types.PermissionDisplay{put, del},
+			to:   []types.PermissionDisplay{del, put},
+		},
+		{
+			name:  "permission added",
+			from:  []types.PermissionDisplay{put},
+			to:    []types.PermissionDisplay{put, del},
+			added: []types.PermissionDisplay{del},
+		},
+		{
+			name:    "permission removed",
+			from:    []types.PermissionDisplay{put, del},
+			to:      []types.PermissionDisplay{put},
+			removed: []types.PermissionDisplay{del},
+		},
+		{
+			name:    "condition added",
+			from:    []types.PermissionDisplay{put},
+			to:      []types.PermissionDisplay{vpcPut},
+			added:   []types.PermissionDisplay{vpcPut},
+			removed: []types.PermissionDisplay{put},
+		},
+		{
+			name:    "permissions replaced",
+			from:    []types.PermissionDisplay{put, del},
+			to:      []types.PermissionDisplay{vpcPut},
+			added:   []types.PermissionDisplay{vpcPut},
+			removed: []types.PermissionDisplay{put, del},
+		},
+		{
+			name:    "allow turned into a deny",
+			from:    []types.PermissionDisplay{del},
+			to:      []types.PermissionDisplay{denyDel},
+			added:   []types.PermissionDisplay{denyDel},
+			removed: []types.PermissionDisplay{del},
+		},
+		{
+			name:  "initial version",
+			to:    []types.PermissionDisplay{put, put},
+			added: []types.PermissionDisplay{put},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := diffPermissions(tt.from, tt.to)
+			assert.Equal(t, tt.added, added)
+			assert.Equal(t, tt.removed, removed)
+		})
+	}
+}
+
+func TestDescribePermission(t *testing.T) {
+	p := types.PermissionDisplay{
+		Action:   "s3:GetObject",
+		Resource: "arn:aws:s3:::reports/*",
+		Effect:   "Allow",
+		Conditions: []types.Condition{
+			{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}},
+			{Operator: "Bool", Key: "aws:SecureTransport", Values: []string{"true"}},
+		},
+	}
+	assert.Equal(t, "Allow s3:GetObject on arn:aws:s3:::reports/* if aws:SourceVpc StringEquals vpc-123 and aws:SecureTransport Bool true",
+		describePermission(p))
+}
+
+func TestPrintHistory(t *testing.T) {
+	pod := types.PodPermissions{
+		PodName:   "api",
+		Namespace: "payments",
+		IAMRole:   "arn:aws:iam::123456789012:role/api",
+		PolicyHistory: []types.PolicyVersion{
+			{
+				VersionID:  "v2",
+				CreateDate: time.Date(2024, 5, 2, 10, 14, 0, 0, time.UTC),
+				IsDefault:  true,
+				Permissions: []types.PermissionDisplay{
+					{Action: "s3:PutObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+					{Action: "s3:DeleteObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+				},
+			},
+			{
+				VersionID:  "v1",
+				CreateDate: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC),
+				Permissions: []types.PermissionDisplay{
+					{Action: "s3:PutObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		versions []string
+		wantErr  string
+	}{
+		{name: "all versions"},
+		{name: "two versions", versions: []string{"v1", "v2"}},
+		{name: "unknown version", versions: []string{"v1", "v7"}, wantErr: "policy has no version v7; IAM keeps v2, v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &options.Options{Command: options.CommandHistory, PolicyName: "reports-writer", Versions: tt.versions}
+			err := PrintHistory([]types.PodPermissions{pod}, opts)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"strings"
 
 	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/risk"
 	"github.com/berkguzel/pperm/pkg/types"
 )
 
@@ -17,67 +20,172 @@ func New(w io.Writer) *Printer {
 	return &Printer{writer: w}
 }
 
+// Widths of the NAMESPACE, POD and SOURCE columns in multi-pod reports, and
+// of the USAGE column
+const (
+	namespaceColumnWidth = 20
+	podColumnWidth       = 30
+	sourceColumnWidth    = 16
+	usageColumnWidth     = 12
+)
+
+// podColumns describes the optional columns of the tables: the
+// pod-identifying columns prepended when a report covers more than one pod,
+// followed by the mechanism that granted the pod its role, and the USAGE
+// column appended to the permissions table with --unused
+type podColumns struct {
+	namespace bool
+	pod       bool
+	usage     bool
+}
+
+func newPodColumns(opts *options.Options) podColumns {
+	return podColumns{
+		namespace: opts.AllNamespaces,
+		pod:       opts.AllNamespaces || !opts.HasTarget(),
+		usage:     opts.Unused,
+	}
+}
+
+func (c podColumns) header() string {
+	var b strings.Builder
+	if c.namespace {
+		fmt.Fprintf(&b, "| %-*s ", namespaceColumnWidth, "NAMESPACE")
+	}
+	if c.pod {
+		fmt.Fprintf(&b, "| %-*s | %-*s ", podColumnWidth, "POD", sourceColumnWidth, "SOURCE")
+	}
+	return b.String()
+}
+
+func (c podColumns) separator() string {
+	var b strings.Builder
+	if c.namespace {
+		b.WriteString("+" + strings.Repeat("-", namespaceColumnWidth+2))
+	}
+	if c.pod {
+		b.WriteString("+" + strings.Repeat("-", podColumnWidth+2))
+		b.WriteString("+" + strings.Repeat("-", sourceColumnWidth+2))
+	}
+	return b.String()
+}
+
+// usageCell renders the USAGE cell, or nothing without the column
+func (c podColumns) usageCell(usage string) string {
+	if !c.usage {
+		return ""
+	}
+	return fmt.Sprintf(" %-*s |", usageColumnWidth, usage)
+}
+
+func (c podColumns) usageSeparator() string {
+	if !c.usage {
+		return ""
+	}
+	return strings.Repeat("-", usageColumnWidth+2) + "+"
+}
+
+func (c podColumns) cells(pod types.PodPermissions) string {
+	var b strings.Builder
+	if c.namespace {
+		fmt.Fprintf(&b, "| %-*s ", namespaceColumnWidth, truncateString(pod.Namespace, namespaceColumnWidth))
+	}
+	if c.pod {
+		source := pod.CredentialSource
+		if source == "" {
+			source = "-"
+		}
+		fmt.Fprintf(&b, "| %-*s | %-*s ", podColumnWidth, truncateString(pod.PodName, podColumnWidth),
+			sourceColumnWidth, truncateString(source, sourceColumnWidth))
+	}
+	return b.String()
+}
+
 func Print(perms []types.PodPermissions, opts *options.Options) error {
 	if opts.InspectPolicy {
 		return inspectPolicy(perms, opts)
 	}
 
+	columns := newPodColumns(opts)
+	if opts.SortBy == options.SortByRisk {
+		perms = sortByRisk(perms)
+	}
+	if !columns.pod {
+		printRoles(perms)
+	}
+
 	if opts.ShowPerms || opts.RiskOnly {
 		// Calculate max resource length
 		maxResourceLen := 52 // minimum width
 		for _, perm := range perms {
 			for _, policy := range perm.Policies {
 				for _, p := range policy.Permissions {
-					if len(p.Resource) > maxResourceLen {
-						maxResourceLen = len(p.Resource) + 2 // add some padding
+					if resourceCellWidth(p) > maxResourceLen {
+						maxResourceLen = resourceCellWidth(p) + 2 // add some padding
 					}
 				}
 			}
 		}
 
 		// Print table header with separator
-		printPermissionsTableHeader(maxResourceLen)
+		printPermissionsTableHeader(maxResourceLen, columns)
 
 		// Print permissions
 		for _, perm := range perms {
-			for _, policy := range perm.Policies {
-				for _, p := range policy.Permissions {
-					scope := " ✅ "
-					if p.IsBroad || p.IsHighRisk {
-						scope = " 🚨 "
-					}
-
-					// Skip if risk-only flag is set and permission doesn't have broad scope
-					if opts.RiskOnly && scope != " 🚨 " {
-						continue
-					}
+			printPermissionRows(perm, perm.Policies, maxResourceLen, columns, opts)
+		}
 
-					fmt.Printf("| %-30s | %-35s | %-*s | %-4s |\n",
-						truncateString(policy.Name, 30),
-						p.Action,
-						maxResourceLen,
-						p.Resource,
-						scope,
-					)
+		// Print table footer
+		printPermissionsSeparator(maxResourceLen, columns)
+
+		// Permissions boundaries cap what the policies above grant, so show
+		// what is left of them as a second table
+		if hasPermissionsBoundary(perms) {
+			fmt.Println("\nWithin permissions boundary:")
+			printPermissionsTableHeader(maxResourceLen, columns)
+			for _, perm := range perms {
+				if perm.PermissionsBoundary != nil {
+					printPermissionRows(perm, perm.BoundedPolicies, maxResourceLen, columns, opts)
 				}
 			}
+			printPermissionsSeparator(maxResourceLen, columns)
 		}
 
-		// Print table footer
-		printPermissionsSeparator(maxResourceLen)
+		printDenialLegend(perms)
+		printContextLegend(perms)
+		if opts.Unused {
+			printUsageSummary(perms, opts.UnusedDays)
+		}
+		printBoundaryNotes(perms)
+		printRiskSummary(perms)
+		printEscalationPaths(perms)
+		printNodeCredentialWarnings(perms)
+		printScanErrors(perms)
 		return nil
 	}
 
 	// Default case: show policy overview table
-	printPolicyTableHeader()
+	printPolicyTableHeader(columns)
 
 	for _, perm := range perms {
+		// Keep pods without an IAM role, or whose role could not be looked
+		// up, visible in namespace scans
+		if columns.pod && (perm.IAMRole == "" || perm.Error != "") && !opts.RiskOnly {
+			label := "(no IAM role)"
+			if perm.Error != "" {
+				label = "(lookup failed)"
+			}
+			fmt.Printf("%s| %-30s | %-7s | %-7s | %-14s | %-10s | %-12s | %-*s |\n",
+				columns.cells(perm), label, "-", "-", "-", "-", "-", riskColumnWidth, "-")
+			continue
+		}
+
 		for _, policy := range perm.Policies {
 			// Skip if risk-only flag is set and no high-risk permissions
 			if opts.RiskOnly {
 				hasRisk := false
 				for _, p := range policy.Permissions {
-					if p.IsBroad || p.IsHighRisk {
+					if p.IsHighRisk {
 						hasRisk = true
 						break
 					}
@@ -87,8 +195,8 @@ func Print(perms []types.PodPermissions, opts *options.Options) error {
 				}
 			}
 
-			// Determine access level based on permissions and policy name
-			accessLevel := determineAccessLevel(policy.Permissions, policy.Name)
+			// Determine the most privileged access level the policy grants
+			accessLevel := shortAccessLevel(determineAccessLevel(policy.Permissions))
 			// Determine service based on actions in permissions
 			service := determineService(policy.Permissions)
 			// Determine resource scope
@@ -96,74 +204,305 @@ func Print(perms []types.PodPermissions, opts *options.Options) error {
 			// Determine if there are conditions
 			condition := determineConditions(policy)
 
-			fmt.Printf("| %-30s | %-7s | %-14s | %-10s | %-12s |\n",
+			fmt.Printf("%s| %-30s | %-7s | %-7s | %-14s | %-10s | %-12s | %-*s |\n",
+				columns.cells(perm),
 				truncateString(policy.Name, 30),
+				policyType(policy),
 				truncateString(service, 7),
 				truncateString(accessLevel, 14),
 				truncateString(resource, 10),
 				truncateString(condition, 12),
+				riskColumnWidth,
+				risk.Severity(policy.Score),
 			)
 		}
 	}
 
-	printPolicySeparator()
+	printPolicySeparator(columns)
+	printBoundaryNotes(perms)
+	printRiskSummary(perms)
+	printEscalationPaths(perms)
+	printNodeCredentialWarnings(perms)
+	printScanErrors(perms)
 	return nil
 }
 
-func determineAccessLevel(permissions []types.PermissionDisplay, policyName string) string {
-	hasFullAccess := false
-	hasLimitedAccess := false
-	readOnly := true
+// printPermissionRows prints one permissions table row per permission of the
+// given policies of a pod
+func printPermissionRows(perm types.PodPermissions, policies []types.Policy, resourceWidth int, columns podColumns, opts *options.Options) {
+	for _, policy := range policies {
+		for _, p := range policy.Permissions {
+			scope := permissionScope(p)
+
+			// Skip if risk-only flag is set and permission doesn't have broad scope
+			if opts.RiskOnly && scope != " 🚨 " {
+				continue
+			}
 
-	// First check if the policy name itself indicates full access
-	policyNameLower := strings.ToLower(policyName)
-	if strings.Contains(policyNameLower, "readonly") {
-		return "Read-Only"
+			fmt.Printf("%s| %-30s | %s | %-*s | %-4s | %-*s |%s\n",
+				columns.cells(perm),
+				truncateString(policy.Name, 30),
+				padCell(actionCell(p), 35),
+				resourceWidth,
+				p.ResourceLabel(),
+				scope,
+				riskColumnWidth,
+				riskCell(p),
+				columns.usageCell(usageCell(p, perm.Usage, opts.UnusedDays)),
+			)
+
+			printConditionRows(perm, p, resourceWidth, columns)
+			if opts.ExpandActions {
+				printExpandedActionRows(perm, p, resourceWidth, columns, opts.UnusedDays)
+			}
+		}
 	}
-	if strings.Contains(policyNameLower, "fullaccesss") ||
-		strings.Contains(policyNameLower, "full-access") ||
-		strings.Contains(policyNameLower, "fullaccess") {
-		hasFullAccess = true
+}
+
+// permissionScope is the SCOPE cell of a permission. Deny statements, Allows
+// that an explicit Deny fully overrides and statements whose conditions fail
+// in the request context grant nothing, so they are told apart from real
+// grants.
+func permissionScope(p types.PermissionDisplay) string {
+	switch {
+	case p.IsInapplicable():
+		return " 🔒 "
+	case p.Effect == "Deny":
+		return " 🚫 "
+	case p.Denial == types.DenialFull:
+		return " ⛔ "
+	case p.IsHighRisk:
+		return " 🚨 "
+	default:
+		return " ✅ "
 	}
+}
 
-	for _, p := range permissions {
-		action := strings.ToLower(p.Action)
+// printDenialLegend explains the SCOPE markers of denied permissions when
+// the reported policies contain any
+func printDenialLegend(perms []types.PodPermissions) {
+	for _, perm := range perms {
+		if hasDenials(perm.Policies) || hasDenials(perm.BoundedPolicies) {
+			fmt.Println("🚫 explicit Deny  ⛔ allowed, but overridden by an explicit Deny")
+			return
+		}
+	}
+}
+
+// printContextLegend explains the SCOPE marker of statements whose
+// conditions fail in the request context given with --context
+func printContextLegend(perms []types.PodPermissions) {
+	for _, perm := range perms {
+		if hasInapplicable(perm.Policies) || hasInapplicable(perm.BoundedPolicies) {
+			fmt.Println("🔒 conditions do not hold in the given context")
+			return
+		}
+	}
+}
+
+func hasInapplicable(policies []types.Policy) bool {
+	for _, policy := range policies {
+		for _, p := range policy.Permissions {
+			if p.IsInapplicable() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// grants reports whether a permission is an Allow that takes effect: one no
+// explicit Deny fully overrides and whose conditions do not fail in the
+// request context
+func grants(p types.PermissionDisplay) bool {
+	return p.Effect == "Allow" && p.Denial != types.DenialFull && !p.IsInapplicable()
+}
+
+func hasDenials(policies []types.Policy) bool {
+	for _, policy := range policies {
+		for _, p := range policy.Permissions {
+			if p.Effect == "Deny" || p.Denial != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasPermissionsBoundary(perms []types.PodPermissions) bool {
+	for _, perm := range perms {
+		if perm.PermissionsBoundary != nil {
+			return true
+		}
+	}
+	return false
+}
 
-		// Check for full access indicators
-		if action == "*" || strings.HasSuffix(action, ":*") {
-			hasFullAccess = true
+// printBoundaryNotes summarizes how much of each bounded role's permissions
+// survive its permissions boundary
+func printBoundaryNotes(perms []types.PodPermissions) {
+	for _, perm := range perms {
+		if perm.PermissionsBoundary == nil {
 			continue
 		}
 
-		// Check for read-only actions
-		if strings.HasPrefix(action, "get") ||
-			strings.HasPrefix(action, "list") ||
-			strings.HasPrefix(action, "describe") ||
-			strings.HasPrefix(action, "head") {
+		name := perm.PodName
+		if name == "" {
+			name = perm.Workload
+		}
+		if name == "" {
+			name = "sa/" + perm.ServiceAccount
+		}
+		fmt.Printf("%s/%s is limited by permissions boundary %s (%d of %d permissions remain)\n",
+			perm.Namespace, name, perm.PermissionsBoundary.Name,
+			countPermissions(perm.BoundedPolicies), countPermissions(perm.Policies))
+	}
+}
+
+func countPermissions(policies []types.Policy) int {
+	count := 0
+	for _, policy := range policies {
+		count += len(policy.Permissions)
+	}
+	return count
+}
+
+// printNodeCredentialWarnings calls out pods whose permissions come from the
+// node's instance profile rather than a role of their own, since every pod
+// on that node shares them
+func printNodeCredentialWarnings(perms []types.PodPermissions) {
+	for _, perm := range perms {
+		if perm.CredentialSource != types.CredentialSourceNode {
 			continue
 		}
 
-		// If we get here, it's a write/modify action
-		hasLimitedAccess = true
-		readOnly = false
+		name := perm.PodName
+		if name == "" {
+			name = perm.Workload
+		}
+		fmt.Printf("%s %s/%s has no IAM role of its own and inherits node credentials from %s\n",
+			warning, perm.Namespace, name, perm.IAMRole)
+	}
+}
+
+// printRoles names the role of each reported target and the mechanism that
+// granted it, for reports that have no POD and SOURCE columns
+func printRoles(perms []types.PodPermissions) {
+	for _, perm := range perms {
+		if perm.IAMRole != "" {
+			fmt.Printf("IAM Role: %s (%s)\n", perm.IAMRole, perm.CredentialSource)
+		}
+	}
+}
+
+// printScanErrors lists the pods of a scan whose role or policies could not
+// be looked up, and why
+func printScanErrors(perms []types.PodPermissions) {
+	for _, perm := range perms {
+		if perm.Error != "" {
+			fmt.Printf("%s %s/%s: %s\n", warning, perm.Namespace, perm.PodName, perm.Error)
+		}
+	}
+}
+
+// determineAccessLevel is the most privileged access level, as AWS
+// classifies actions, that the policy grants. Actions missing from the
+// action catalog cannot be classified and only count when nothing else can.
+func determineAccessLevel(permissions []types.PermissionDisplay) string {
+	levels, unclassified := accessBreakdown(permissions)
+
+	for i := len(catalog.AccessLevels) - 1; i >= 0; i-- {
+		if levels[catalog.AccessLevels[i]] > 0 {
+			return catalog.AccessLevels[i]
+		}
 	}
 
-	// If we have any limited access actions, the whole policy is limited
-	if hasLimitedAccess {
-		return "Limited Access"
+	if len(unclassified) > 0 {
+		return "Unknown"
+	}
+	return "None"
+}
+
+// accessBreakdown counts the distinct actions the policy's grants cover per
+// access level. Grants an explicit Deny fully overrides, or whose conditions
+// fail in the request context, are left out. The action patterns outside the
+// action catalog are returned separately.
+func accessBreakdown(permissions []types.PermissionDisplay) (map[string]int, []string) {
+	actions := make(map[string]string)
+	var unclassified []string
+	seen := make(map[string]bool)
+
+	for _, p := range permissions {
+		if !grants(p) {
+			continue
+		}
+
+		var expanded []catalog.Action
+		var known bool
+		switch {
+		case p.NotAction:
+			// Everything the catalog knows except what is excluded
+			all, _ := catalog.Default().Expand("*")
+			for _, a := range all {
+				if !evaluator.MatchAction(p.Action, a.Name) {
+					expanded = append(expanded, a)
+				}
+			}
+		case strings.ContainsAny(p.Action, "*?"):
+			expanded, known = catalog.Default().Expand(p.Action)
+		default:
+			var a catalog.Action
+			a, known = catalog.Default().Lookup(p.Action)
+			if known {
+				expanded = []catalog.Action{a}
+			}
+		}
+
+		for _, a := range expanded {
+			actions[a.Name] = a.AccessLevel
+		}
+
+		// Wildcards spanning services the catalog lacks are only partly
+		// classified
+		if !known && !seen[p.ActionLabel()] {
+			seen[p.ActionLabel()] = true
+			unclassified = append(unclassified, p.ActionLabel())
+		}
 	}
 
-	// If we have full access and no limited actions
-	if hasFullAccess {
-		return "Full Access"
+	levels := make(map[string]int)
+	for _, level := range actions {
+		levels[level]++
 	}
+	return levels, unclassified
+}
 
-	// If all actions are read-only
-	if readOnly {
-		return "Read-Only"
+// shortAccessLevel fits an access level into the overview table
+func shortAccessLevel(level string) string {
+	if level == catalog.AccessPermissions {
+		return "Permissions"
 	}
+	return level
+}
 
-	return "Limited Access"
+// printAccessBreakdown prints how many actions of each access level a
+// policy grants
+func printAccessBreakdown(policy types.Policy) {
+	levels, unclassified := accessBreakdown(policy.Permissions)
+
+	fmt.Println("Access Levels:")
+	for _, level := range catalog.AccessLevels {
+		switch count := levels[level]; count {
+		case 0:
+		case 1:
+			fmt.Printf("  %-24s 1 action\n", level)
+		default:
+			fmt.Printf("  %-24s %d actions\n", level, count)
+		}
+	}
+	if len(unclassified) > 0 {
+		fmt.Printf("  %-24s %s\n", "Not in action catalog", strings.Join(unclassified, ", "))
+	}
 }
 
 func determineService(permissions []types.PermissionDisplay) string {
@@ -197,19 +536,44 @@ func determineResourceScope(permissions []types.PermissionDisplay) string {
 	return "Single"
 }
 
+// determineConditions reports whether a policy has conditions and, once
+// they were all evaluated against a request context, whether they hold
 func determineConditions(policy types.Policy) string {
-	hasConditions := false
+	conditional, hold, fail := 0, 0, 0
 	for _, perm := range policy.Permissions {
-		if perm.HasCondition {
-			hasConditions = true
-			break
+		if !perm.HasCondition {
+			continue
+		}
+		conditional++
+		switch perm.ConditionResult {
+		case types.ConditionsHold:
+			hold++
+		case types.ConditionsFail:
+			fail++
 		}
 	}
 
-	if hasConditions {
+	switch {
+	case conditional == 0:
+		return "No"
+	case hold == conditional:
+		return "Met"
+	case fail == conditional:
+		return "Not met"
+	case hold+fail == conditional:
+		return "Partly met"
+	default:
 		return "Yes"
 	}
-	return "No"
+}
+
+// policyType reports how a policy is attached to its role, defaulting to
+// managed
+func policyType(policy types.Policy) string {
+	if policy.Type == "" {
+		return types.PolicyTypeManaged
+	}
+	return policy.Type
 }
 
 func truncateString(s string, maxLen int) string {
@@ -219,37 +583,48 @@ func truncateString(s string, maxLen int) string {
 	return s
 }
 
-func printPolicyTableHeader() {
-	printPolicySeparator()
-	fmt.Printf("| %-30s | %-7s | %-14s | %-10s | %-12s |\n",
+func printPolicyTableHeader(columns podColumns) {
+	printPolicySeparator(columns)
+	fmt.Printf("%s| %-30s | %-7s | %-7s | %-14s | %-10s | %-12s | %-*s |\n",
+		columns.header(),
 		"POLICY NAME",
+		"TYPE",
 		"SERVICE",
 		"ACCESS LEVEL",
 		"RESOURCE",
 		"CONDITION",
+		riskColumnWidth,
+		"RISK",
 	)
-	printPolicySeparator()
+	printPolicySeparator(columns)
 }
 
-func printPolicySeparator() {
-	fmt.Println("+--------------------------------+---------+----------------+------------+--------------+")
+func printPolicySeparator(columns podColumns) {
+	fmt.Println(columns.separator() + "+--------------------------------+---------+---------+----------------+------------+--------------+----------+")
 }
 
-func printPermissionsTableHeader(resourceWidth int) {
-	printPermissionsSeparator(resourceWidth)
-	fmt.Printf("| %-30s | %-35s | %-*s | %-5s |\n",
+func printPermissionsTableHeader(resourceWidth int, columns podColumns) {
+	printPermissionsSeparator(resourceWidth, columns)
+	fmt.Printf("%s| %-30s | %-35s | %-*s | %-5s | %-*s |%s\n",
+		columns.header(),
 		"POLICY",
 		"ACTION",
 		resourceWidth,
 		"RESOURCE",
 		"SCOPE",
+		riskColumnWidth,
+		"RISK",
+		columns.usageCell("USAGE"),
 	)
-	printPermissionsSeparator(resourceWidth)
+	printPermissionsSeparator(resourceWidth, columns)
 }
 
-func printPermissionsSeparator(resourceWidth int) {
-	fmt.Printf("+--------------------------------+-------------------------------------+%s+-------+\n",
-		strings.Repeat("-", resourceWidth+2))
+func printPermissionsSeparator(resourceWidth int, columns podColumns) {
+	fmt.Printf("%s+--------------------------------+-------------------------------------+%s+-------+%s+%s\n",
+		columns.separator(),
+		strings.Repeat("-", resourceWidth+2),
+		strings.Repeat("-", riskColumnWidth+2),
+		columns.usageSeparator())
 }
 
 func padRight(str string, length int) string {
@@ -268,6 +643,8 @@ func centerText(text string, width int) string {
 	return strings.Repeat(" ", leftPad) + text + strings.Repeat(" ", rightPad)
 }
 
+// inspectPolicy lists the policies of the single pod, workload or service
+// account the options allow --inspect-policy for, and details the one picked
 func inspectPolicy(perms []types.PodPermissions, opts *options.Options) error {
 	if len(perms) == 0 {
 		fmt.Println("No pod permissions found")
@@ -275,9 +652,23 @@ func inspectPolicy(perms []types.PodPermissions, opts *options.Options) error {
 	}
 
 	pod := perms[0]
-	fmt.Printf("\nPod: %s\n", pod.PodName)
+	switch {
+	case pod.Workload != "":
+		fmt.Printf("\nWorkload: %s\n", pod.Workload)
+	case pod.PodName != "":
+		fmt.Printf("\nPod: %s\n", pod.PodName)
+	default:
+		fmt.Println()
+	}
 	fmt.Printf("Service Account: %s\n", pod.ServiceAccount)
-	fmt.Printf("IAM Role: %s\n\n", pod.IAMRole)
+	fmt.Printf("IAM Role: %s\n", pod.IAMRole)
+	if pod.CredentialSource != "" {
+		fmt.Printf("Credential Source: %s\n", pod.CredentialSource)
+	}
+	if pod.PermissionsBoundary != nil {
+		fmt.Printf("Permissions Boundary: %s\n", pod.PermissionsBoundary.Name)
+	}
+	fmt.Println()
 
 	if len(pod.Policies) == 0 {
 		fmt.Println("No policies attached to this pod")
@@ -288,7 +679,7 @@ func inspectPolicy(perms []types.PodPermissions, opts *options.Options) error {
 	fmt.Println("Available Policies:")
 	fmt.Println("------------------")
 	for i, policy := range pod.Policies {
-		fmt.Printf("%d. %s\n", i+1, policy.Name)
+		fmt.Printf("%d. %s (%s)\n", i+1, policy.Name, policyType(policy))
 	}
 
 	// Get user selection
@@ -303,48 +694,61 @@ func inspectPolicy(perms []types.PodPermissions, opts *options.Options) error {
 	// Display selected policy details
 	selectedPolicy := pod.Policies[choice-1]
 	fmt.Printf("\nPolicy: %s\n", selectedPolicy.Name)
-	fmt.Printf("ARN: %s\n\n", selectedPolicy.Arn)
+	if selectedPolicy.Arn != "" {
+		fmt.Printf("ARN: %s\n", selectedPolicy.Arn)
+	}
+	fmt.Printf("Type: %s\n\n", policyType(selectedPolicy))
 
 	// Calculate max resource length
 	maxResourceLen := 52 // minimum width
 	for _, p := range selectedPolicy.Permissions {
-		if len(p.Resource) > maxResourceLen {
-			maxResourceLen = len(p.Resource) + 2 // add some padding
+		if resourceCellWidth(p) > maxResourceLen {
+			maxResourceLen = resourceCellWidth(p) + 2 // add some padding
 		}
 	}
 
 	// Print permissions table
 	fmt.Println("Permissions:")
 	fmt.Println("-----------")
-	printPermissionsTableHeader(maxResourceLen)
-
-	for _, p := range selectedPolicy.Permissions {
-		scope := " ✅ "
-		if p.IsBroad || p.IsHighRisk {
-			scope = " 🚨 "
-		}
-
-		// Skip if risk-only flag is set and permission doesn't have broad scope
-		if opts.RiskOnly && scope != " 🚨 " {
-			continue
-		}
-
-		fmt.Printf("| %-30s | %-35s | %-*s | %-4s |\n",
-			truncateString(selectedPolicy.Name, 30),
-			p.Action,
-			maxResourceLen,
-			p.Resource,
-			scope,
-		)
+	columns := podColumns{usage: opts.Unused}
+	printPermissionsTableHeader(maxResourceLen, columns)
+	printPermissionRows(pod, pod.Policies[choice-1:choice], maxResourceLen, columns, opts)
+	printPermissionsSeparator(maxResourceLen, columns)
+
+	if pod.PermissionsBoundary != nil && choice <= len(pod.BoundedPolicies) {
+		fmt.Printf("\nWithin permissions boundary %s:\n", pod.PermissionsBoundary.Name)
+		printPermissionsTableHeader(maxResourceLen, columns)
+		printPermissionRows(pod, pod.BoundedPolicies[choice-1:choice], maxResourceLen, columns, opts)
+		printPermissionsSeparator(maxResourceLen, columns)
 	}
 
-	printPermissionsSeparator(maxResourceLen)
+	printConditionDetails(selectedPolicy)
+
+	// Drill into what each wildcard action actually grants
+	printWildcardActions(selectedPolicy)
 
 	// Show additional policy information
-	fmt.Printf("\nAccess Level: %s\n", determineAccessLevel(selectedPolicy.Permissions, selectedPolicy.Name))
+	fmt.Printf("\nAccess Level: %s\n", determineAccessLevel(selectedPolicy.Permissions))
 	fmt.Printf("Service: %s\n", determineService(selectedPolicy.Permissions))
 	fmt.Printf("Resource Scope: %s\n", determineResourceScope(selectedPolicy.Permissions))
 	fmt.Printf("Has Conditions: %s\n", determineConditions(selectedPolicy))
+	fmt.Printf("Risk: %s\n", describeScore(selectedPolicy.Score))
+	fmt.Println()
+	printAccessBreakdown(selectedPolicy)
+
+	// Name the Deny behind every overridden Allow, which the table only marks
+	for _, p := range selectedPolicy.Permissions {
+		if p.Denial != "" {
+			fmt.Printf("%s %s on %s is %s by %s\n", warning, p.ActionLabel(), p.ResourceLabel(), p.Denial, p.DeniedBy)
+		}
+	}
+
+	// Name the risk rules each permission matches
+	for _, p := range selectedPolicy.Permissions {
+		for _, rule := range p.RiskRules {
+			fmt.Printf("%s %s on %s: %s\n", warning, p.ActionLabel(), p.ResourceLabel(), rule)
+		}
+	}
 
 	return nil
 }
@@ -2,6 +2,7 @@ package printer
 
 import (
 	"testing"
+	"time"
 
 	"github.com/berkguzel/pperm/internal/options"
 	"github.com/berkguzel/pperm/pkg/types"
@@ -44,6 +45,275 @@ func TestPrint(t *testing.T) {
 			opts:           &options.Options{},
 			expectedOutput: "POLICY NAME",
 		},
+		{
+			name: "namespace scan",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:        "api",
+					Namespace:      "payments",
+					ServiceAccount: "api-sa",
+					IAMRole:        "test-role",
+					Policies: []types.Policy{
+						{
+							Name: "TestPolicy",
+							Arn:  "arn:aws:iam::test-policy",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:GetObject", Resource: "*", Effect: "Allow"},
+							},
+						},
+					},
+				},
+				{
+					PodName:        "worker",
+					Namespace:      "payments",
+					ServiceAccount: "default",
+				},
+			},
+			opts:           &options.Options{Namespace: "payments"},
+			expectedOutput: "POD",
+		},
+		{
+			name: "namespace scan with lookup failures",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:        "api",
+					Namespace:      "payments",
+					ServiceAccount: "api-sa",
+					Error:          "failed to resolve IAM role: serviceaccounts \"api-sa\" is forbidden",
+				},
+				{
+					PodName:        "worker",
+					Namespace:      "payments",
+					ServiceAccount: "worker-sa",
+					IAMRole:        "worker-role",
+					Error:          "failed to get policies for role worker-role: access denied",
+				},
+			},
+			opts:           &options.Options{Namespace: "payments"},
+			expectedOutput: "lookup failed",
+		},
+		{
+			name: "all namespaces permissions",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:   "api",
+					Namespace: "payments",
+					IAMRole:   "test-role",
+					Policies: []types.Policy{
+						{
+							Name: "TestPolicy",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true},
+							},
+						},
+					},
+				},
+			},
+			opts:           &options.Options{AllNamespaces: true, ShowPerms: true},
+			expectedOutput: "NAMESPACE",
+		},
+		{
+			name: "inline policy",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:        "test-pod",
+					Namespace:      "default",
+					ServiceAccount: "test-sa",
+					IAMRole:        "test-role",
+					Policies: []types.Policy{
+						{
+							Name: "inline-admin",
+							Type: types.PolicyTypeInline,
+							Permissions: []types.PermissionDisplay{
+								{Action: "iam:*", Resource: "*", Effect: "Allow", IsBroad: true, IsHighRisk: true},
+							},
+						},
+					},
+				},
+			},
+			opts:           &options.Options{PodName: "test-pod"},
+			expectedOutput: "inline",
+		},
+		{
+			name: "node credentials",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:          "legacy",
+					Namespace:        "default",
+					ServiceAccount:   "default",
+					IAMRole:          "arn:aws:iam::123456789012:role/node-role",
+					CredentialSource: types.CredentialSourceNode,
+					Policies: []types.Policy{
+						{
+							Name: "AmazonEKSWorkerNodePolicy",
+							Permissions: []types.PermissionDisplay{
+								{Action: "ec2:DescribeInstances", Resource: "*", Effect: "Allow"},
+							},
+						},
+					},
+				},
+			},
+			opts:           &options.Options{PodName: "legacy"},
+			expectedOutput: "node credentials",
+		},
+		{
+			name: "permissions boundary",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:        "api",
+					Namespace:      "default",
+					ServiceAccount: "api-sa",
+					IAMRole:        "bounded-role",
+					Policies: []types.Policy{
+						{
+							Name: "AmazonS3FullAccess",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, IsHighRisk: true},
+							},
+						},
+					},
+					PermissionsBoundary: &types.Policy{
+						Name: "read-only-boundary",
+						Type: types.PolicyTypeBoundary,
+						Permissions: []types.PermissionDisplay{
+							{Action: "s3:GetObject", Resource: "*", Effect: "Allow", IsBroad: true},
+						},
+					},
+					BoundedPolicies: []types.Policy{
+						{
+							Name: "AmazonS3FullAccess",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:GetObject", Resource: "*", Effect: "Allow", IsBroad: true},
+							},
+						},
+					},
+				},
+			},
+			opts:           &options.Options{PodName: "api", ShowPerms: true},
+			expectedOutput: "Within permissions boundary",
+		},
+		{
+			name: "conditions",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:   "test-pod",
+					Namespace: "default",
+					IAMRole:   "test-role",
+					Policies: []types.Policy{
+						{
+							Name: "reports",
+							Permissions: []types.PermissionDisplay{
+								{
+									Action: "s3:ListBucket", Resource: "arn:aws:s3:::reports", Effect: "Allow", HasCondition: true,
+									Conditions: []types.Condition{
+										{Operator: "StringEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1a2b3c4d"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			opts: &options.Options{ShowPerms: true},
+		},
+		{
+			name: "explicit deny",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:   "api",
+					Namespace: "default",
+					IAMRole:   "test-role",
+					Policies: []types.Policy{
+						{
+							Name: "AmazonS3FullAccess",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, IsHighRisk: true, Denial: types.DenialPartial, DeniedBy: "guardrails"},
+							},
+						},
+						{
+							Name: "guardrails",
+							Type: types.PolicyTypeInline,
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:Delete*", Resource: "*", Effect: "Deny", IsBroad: true},
+							},
+						},
+					},
+				},
+			},
+			opts:           &options.Options{PodName: "api", ShowPerms: true},
+			expectedOutput: "explicit Deny",
+		},
+		{
+			name: "unused permissions",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:   "api",
+					Namespace: "default",
+					IAMRole:   "test-role",
+					Policies: []types.Policy{
+						{
+							Name: "app",
+							Permissions: []types.PermissionDisplay{
+								{Action: "s3:GetObject", Resource: "*", Effect: "Allow"},
+								{Action: "dynamodb:*", Resource: "*", Effect: "Allow", IsBroad: true},
+							},
+						},
+					},
+					Usage: []types.ServiceUsage{
+						{Service: "s3", LastUsed: time.Now().Add(-48 * time.Hour), Actions: []types.ActionUsage{
+							{Action: "s3:GetObject", LastUsed: time.Now().Add(-48 * time.Hour)},
+						}},
+						{Service: "dynamodb"},
+					},
+				},
+			},
+			opts:           &options.Options{PodName: "api", ShowPerms: true, Unused: true, UnusedDays: 90},
+			expectedOutput: "USAGE",
+		},
+		{
+			name: "escalation paths",
+			podPerms: []types.PodPermissions{
+				{
+					PodName:   "deployer",
+					Namespace: "ci",
+					IAMRole:   "test-role",
+					Policies: []types.Policy{
+						{
+							Name: "deployer",
+							Permissions: []types.PermissionDisplay{
+								{Action: "iam:PassRole", Resource: "*", Effect: "Allow", IsBroad: true},
+								{Action: "ec2:RunInstances", Resource: "*", Effect: "Allow", IsBroad: true},
+							},
+						},
+					},
+					EscalationPaths: []types.EscalationPath{
+						{
+							Path:        "iam:PassRole + ec2:RunInstances",
+							Description: "launch an instance with a more privileged role and use its credentials",
+							Grants: []types.Grant{
+								{Policy: "deployer", Permission: types.PermissionDisplay{Action: "iam:PassRole", Resource: "*", Effect: "Allow"}},
+								{Policy: "deployer", Permission: types.PermissionDisplay{Action: "ec2:RunInstances", Resource: "*", Effect: "Allow"}},
+							},
+						},
+					},
+				},
+			},
+			opts:           &options.Options{PodName: "deployer"},
+			expectedOutput: "can escalate its privileges",
+		},
+		{
+			name: "namespace scan sorted by risk",
+			podPerms: []types.PodPermissions{
+				{PodName: "web", Namespace: "default", IAMRole: "web-role", Score: 20, Policies: []types.Policy{
+					{Name: "read", Score: 20, Permissions: []types.PermissionDisplay{{Action: "s3:GetObject", Resource: "arn:aws:s3:::assets/*", Effect: "Allow", Score: 20}}},
+				}},
+				{PodName: "api", Namespace: "default", IAMRole: "api-role", Score: 95, Policies: []types.Policy{
+					{Name: "admin", Score: 95, Permissions: []types.PermissionDisplay{{Action: "iam:*", Resource: "*", Effect: "Allow", Score: 95}}},
+				}},
+			},
+			opts:           &options.Options{Namespace: "default", SortBy: options.SortByRisk},
+			expectedOutput: "Riskiest pods",
+		},
 		{
 			name:     "empty permissions",
 			podPerms: []types.PodPermissions{},
@@ -59,41 +329,128 @@ func TestPrint(t *testing.T) {
 	}
 }
 
+func TestPermissionScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		perm     types.PermissionDisplay
+		expected string
+	}{
+		{
+			name:     "narrow grant",
+			perm:     types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a", Effect: "Allow"},
+			expected: " ✅ ",
+		},
+		{
+			name:     "high risk grant",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, IsHighRisk: true, Score: 70},
+			expected: " 🚨 ",
+		},
+		{
+			name:     "medium risk grant",
+			perm:     types.PermissionDisplay{Action: "s3:Get*", Resource: "*", Effect: "Allow", IsBroad: true, Score: 40},
+			expected: " ✅ ",
+		},
+		{
+			name:     "deny statement",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Deny", IsBroad: true},
+			expected: " 🚫 ",
+		},
+		{
+			name:     "denied away",
+			perm:     types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Allow", IsHighRisk: true, Denial: types.DenialFull},
+			expected: " ⛔ ",
+		},
+		{
+			name:     "conditions fail in the given context",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, HasCondition: true, ConditionResult: types.ConditionsFail},
+			expected: " 🔒 ",
+		},
+		{
+			name:     "conditions hold in the given context",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, HasCondition: true, ConditionResult: types.ConditionsHold, IsHighRisk: true, Score: 70},
+			expected: " 🚨 ",
+		},
+		{
+			name:     "partially denied stays risky",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, Denial: types.DenialPartial, IsHighRisk: true, Score: 60},
+			expected: " 🚨 ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, permissionScope(tt.perm))
+		})
+	}
+}
+
 func TestDetermineAccessLevel(t *testing.T) {
 	tests := []struct {
 		name        string
 		permissions []types.PermissionDisplay
-		policyName  string
 		expected    string
 	}{
 		{
-			name: "full access",
+			name: "full service access",
+			permissions: []types.PermissionDisplay{
+				{Action: "s3:*", Effect: "Allow"},
+			},
+			expected: "Permissions management",
+		},
+		{
+			name: "read only wildcards",
 			permissions: []types.PermissionDisplay{
-				{Action: "s3:*"},
+				{Action: "s3:Get*", Effect: "Allow"},
+				{Action: "s3:List*", Effect: "Allow"},
 			},
-			policyName: "test-policy",
-			expected:   "Full Access",
+			expected: "Read",
 		},
 		{
-			name: "read only",
+			name: "decrypt is a write despite its verb",
 			permissions: []types.PermissionDisplay{
-				{Action: "s3:Get*"},
-				{Action: "s3:List*"},
-				{Action: "s3:Describe*"},
+				{Action: "s3:GetObject", Effect: "Allow"},
+				{Action: "kms:Decrypt", Effect: "Allow"},
 			},
-			policyName: "ReadOnlyPolicy",
-			expected:   "Read-Only",
+			expected: "Write",
+		},
+		{
+			name: "denied grant does not count",
+			permissions: []types.PermissionDisplay{
+				{Action: "s3:ListBucket", Effect: "Allow"},
+				{Action: "s3:PutBucketPolicy", Effect: "Allow", Denial: types.DenialFull},
+			},
+			expected: "List",
+		},
+		{
+			name: "service missing from catalog",
+			permissions: []types.PermissionDisplay{
+				{Action: "athena:Get*", Effect: "Allow"},
+			},
+			expected: "Unknown",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := determineAccessLevel(tt.permissions, tt.policyName)
+			result := determineAccessLevel(tt.permissions)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
+func TestAccessBreakdown(t *testing.T) {
+	levels, unclassified := accessBreakdown([]types.PermissionDisplay{
+		{Action: "sqs:*Message", Effect: "Allow"},
+		{Action: "sqs:SendMessage", Effect: "Allow"},
+		{Action: "sqs:TagQueue", Effect: "Allow"},
+		{Action: "sqs:DeleteQueue", Effect: "Deny"},
+		{Action: "athena:StartQueryExecution", Effect: "Allow"},
+	})
+
+	assert.Equal(t, map[string]int{"Read": 1, "Write": 2, "Tagging": 1}, levels)
+	assert.Equal(t, []string{"athena:StartQueryExecution"}, unclassified)
+}
+
 func TestDetermineResourceScope(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -150,6 +507,36 @@ func TestDetermineConditions(t *testing.T) {
 			},
 			expected: "No",
 		},
+		{
+			name: "conditions met in the given context",
+			policy: types.Policy{
+				Permissions: []types.PermissionDisplay{
+					{HasCondition: true, ConditionResult: types.ConditionsHold},
+					{HasCondition: false},
+				},
+			},
+			expected: "Met",
+		},
+		{
+			name: "conditions partly met in the given context",
+			policy: types.Policy{
+				Permissions: []types.PermissionDisplay{
+					{HasCondition: true, ConditionResult: types.ConditionsHold},
+					{HasCondition: true, ConditionResult: types.ConditionsFail},
+				},
+			},
+			expected: "Partly met",
+		},
+		{
+			name: "some conditions cannot be evaluated",
+			policy: types.Policy{
+				Permissions: []types.PermissionDisplay{
+					{HasCondition: true, ConditionResult: types.ConditionsFail},
+					{HasCondition: true},
+				},
+			},
+			expected: "Yes",
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,67 @@
+package printer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/berkguzel/pperm/pkg/risk"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// riskColumnWidth is the width of the RISK column, fitting every severity
+const riskColumnWidth = 8
+
+// riskiestPodsShown is how many pods the risk summary of a scan lists
+const riskiestPodsShown = 5
+
+// riskCell is the RISK cell of a permission: the severity of its score, or
+// nothing for statements that grant nothing
+func riskCell(p types.PermissionDisplay) string {
+	if !grants(p) {
+		return ""
+	}
+	return risk.Severity(p.Score)
+}
+
+// sortByRisk returns the pods ordered from the riskiest down, keeping the
+// order of pods that score the same
+func sortByRisk(perms []types.PodPermissions) []types.PodPermissions {
+	sorted := make([]types.PodPermissions, len(perms))
+	copy(sorted, perms)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+	return sorted
+}
+
+// printRiskSummary rates the role of a single pod, or lists the riskiest pods
+// of a scan
+func printRiskSummary(perms []types.PodPermissions) {
+	var scored []types.PodPermissions
+	for _, perm := range perms {
+		if perm.IAMRole != "" && perm.Error == "" {
+			scored = append(scored, perm)
+		}
+	}
+
+	switch len(scored) {
+	case 0:
+		return
+	case 1:
+		fmt.Printf("\nOverall risk: %s\n", describeScore(scored[0].Score))
+		return
+	}
+
+	fmt.Println("\nRiskiest pods:")
+	for i, perm := range sortByRisk(scored) {
+		if i == riskiestPodsShown {
+			break
+		}
+		fmt.Printf("  %-*s %s\n", namespaceColumnWidth+podColumnWidth, perm.Namespace+"/"+displayName(perm), describeScore(perm.Score))
+	}
+}
+
+// describeScore renders a score with its severity, e.g. "High (70)"
+func describeScore(score int) string {
+	return fmt.Sprintf("%s (%d)", risk.Severity(score), score)
+}
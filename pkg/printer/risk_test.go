@@ -0,0 +1,64 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRiskCell(t *testing.T) {
+	tests := []struct {
+		name     string
+		perm     types.PermissionDisplay
+		expected string
+	}{
+		{
+			name:     "grant",
+			perm:     types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Allow", Score: 95},
+			expected: "Critical",
+		},
+		{
+			name:     "low risk grant",
+			perm:     types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a", Effect: "Allow", Score: 10},
+			expected: "Low",
+		},
+		{
+			name: "deny statement",
+			perm: types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Deny"},
+		},
+		{
+			name: "denied away",
+			perm: types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Allow", Denial: types.DenialFull},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, riskCell(tt.perm))
+		})
+	}
+}
+
+func TestSortByRisk(t *testing.T) {
+	perms := []types.PodPermissions{
+		{PodName: "web", Score: 20},
+		{PodName: "api", Score: 95},
+		{PodName: "worker", Score: 20},
+		{PodName: "batch", Score: 45},
+	}
+
+	var names []string
+	for _, perm := range sortByRisk(perms) {
+		names = append(names, perm.PodName)
+	}
+	assert.Equal(t, []string{"api", "batch", "web", "worker"}, names)
+	assert.Equal(t, "web", perms[0].PodName)
+}
+
+func TestDescribeScore(t *testing.T) {
+	assert.Equal(t, "Low (0)", describeScore(0))
+	assert.Equal(t, "Medium (30)", describeScore(30))
+	assert.Equal(t, "High (70)", describeScore(70))
+	assert.Equal(t, "Critical (100)", describeScore(100))
+}
@@ -0,0 +1,126 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/cloudtrail"
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// suggestDateFormat is how the period the CloudTrail logs cover is shown
+const suggestDateFormat = "2006-01-02"
+
+// PrintSuggestion prints a policy granting only the calls each pod's role
+// made according to CloudTrail, followed by the changes replacing the role's
+// current policies with it would make
+func PrintSuggestion(perms []types.PodPermissions, opts *options.Options) error {
+	if len(perms) == 0 {
+		fmt.Println("No pods found")
+		return nil
+	}
+
+	for i, perm := range perms {
+		if len(perms) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s/%s:\n", perm.Namespace, displayName(perm))
+		}
+
+		if perm.IAMRole == "" {
+			fmt.Println("no IAM role")
+			continue
+		}
+		if len(perm.Calls) == 0 {
+			fmt.Printf("No calls of role %s found in the CloudTrail logs in %s\n", perm.IAMRole, opts.CloudTrailDir)
+			continue
+		}
+
+		if err := printSuggestedPolicy(perm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printSuggestedPolicy(perm types.PodPermissions) error {
+	first, last := callPeriod(perm.Calls)
+	fmt.Printf("Based on %d calls to %d actions recorded from %s to %s\n",
+		callCount(perm.Calls), len(uniqueActions(perm.Calls)),
+		first.UTC().Format(suggestDateFormat), last.UTC().Format(suggestDateFormat))
+
+	policy, unknown := cloudtrail.SuggestPolicy(perm.Calls)
+	for _, action := range unknown {
+		fmt.Printf("%s %s was called, but is not a known IAM action and was left out of the policy\n", warning, action)
+	}
+
+	document, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode suggested policy: %v", err)
+	}
+	fmt.Printf("\nSuggested policy:\n%s\n", document)
+
+	fmt.Println("\nChanges to the current policies:")
+	printPermissionChanges(currentGrants(perm.Policies), policy.Permissions())
+
+	for _, call := range perm.Calls {
+		if call.Resource == "*" || !cloudtrail.KnownAction(call.Action) {
+			continue
+		}
+		result := evaluator.EvaluateBounded(perm.Policies, perm.PermissionsBoundary, call.Action, call.Resource)
+		if result.Decision != evaluator.DecisionAllowed {
+			fmt.Printf("%s %s on %s was called, but the current policies do not allow it; a resource-based policy may grant it\n",
+				warning, call.Action, call.Resource)
+		}
+	}
+
+	return nil
+}
+
+// currentGrants returns the Allow permissions of the policies. Denies stay
+// as they are when the suggested policy replaces the others.
+func currentGrants(policies []types.Policy) []types.PermissionDisplay {
+	var grants []types.PermissionDisplay
+	for _, policy := range policies {
+		for _, p := range policy.Permissions {
+			if p.Effect == "Allow" {
+				grants = append(grants, p)
+			}
+		}
+	}
+	return grants
+}
+
+// callPeriod returns when the first and the last of the calls were made
+func callPeriod(calls []types.APICall) (first, last time.Time) {
+	for i, call := range calls {
+		if i == 0 || call.FirstSeen.Before(first) {
+			first = call.FirstSeen
+		}
+		if call.LastSeen.After(last) {
+			last = call.LastSeen
+		}
+	}
+	return first, last
+}
+
+func callCount(calls []types.APICall) int {
+	count := 0
+	for _, call := range calls {
+		count += call.Count
+	}
+	return count
+}
+
+func uniqueActions(calls []types.APICall) map[string]bool {
+	actions := make(map[string]bool)
+	for _, call := range calls {
+		actions[call.Action] = true
+	}
+	return actions
+}
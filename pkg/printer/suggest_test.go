@@ -0,0 +1,55 @@
+package printer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/berkguzel/pperm/internal/options"
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintSuggestion(t *testing.T) {
+	day := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	perms := []types.PodPermissions{
+		{
+			PodName:   "api",
+			Namespace: "default",
+			IAMRole:   "arn:aws:iam::123456789012:role/api",
+			Policies: []types.Policy{
+				{
+					Name: "AmazonS3FullAccess",
+					Permissions: []types.PermissionDisplay{
+						{Action: "s3:*", Resource: "*", Effect: "Allow", IsBroad: true, IsHighRisk: true},
+					},
+				},
+			},
+			Calls: []types.APICall{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a.csv", Count: 3, FirstSeen: day, LastSeen: day.AddDate(0, 0, 6)},
+				{Action: "sqs:SendMessage", Resource: "arn:aws:sqs:us-east-1:123456789012:jobs", Count: 1, FirstSeen: day, LastSeen: day},
+				{Action: "s3:ListObjects", Resource: "arn:aws:s3:::reports", Count: 1, FirstSeen: day, LastSeen: day},
+			},
+		},
+		{PodName: "worker", Namespace: "default", IAMRole: "arn:aws:iam::123456789012:role/worker"},
+		{PodName: "batch", Namespace: "default"},
+	}
+
+	err := PrintSuggestion(perms, &options.Options{Command: options.CommandSuggest, CloudTrailDir: "./logs"})
+	assert.NoError(t, err)
+
+	first, last := callPeriod(perms[0].Calls)
+	assert.Equal(t, day, first)
+	assert.Equal(t, day.AddDate(0, 0, 6), last)
+	assert.Equal(t, 5, callCount(perms[0].Calls))
+}
+
+func TestCurrentGrants(t *testing.T) {
+	allow := types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow"}
+	deny := types.PermissionDisplay{Action: "s3:DeleteObject", Resource: "*", Effect: "Deny"}
+	policies := []types.Policy{
+		{Name: "AmazonS3FullAccess", Permissions: []types.PermissionDisplay{allow}},
+		{Name: "guardrails", Permissions: []types.PermissionDisplay{deny}},
+	}
+
+	assert.Equal(t, []types.PermissionDisplay{allow}, currentGrants(policies))
+}
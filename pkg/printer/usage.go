@@ -0,0 +1,144 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// now is the current time, replaced in tests
+var now = time.Now
+
+// usageCell is the USAGE cell of a permission: how long ago the role last
+// used what it grants, "unused" with that age once it exceeds unusedDays,
+// "never used" when IAM recorded no use, and "-" without last-accessed data.
+// Denies grant nothing to use, so their cell is empty.
+func usageCell(p types.PermissionDisplay, usage []types.ServiceUsage, unusedDays int) string {
+	if p.Effect != "Allow" {
+		return ""
+	}
+
+	lastUsed, known := permissionUsage(p, usage)
+	switch {
+	case !known:
+		return "-"
+	case lastUsed.IsZero():
+		return "never used"
+	}
+
+	days := daysSince(lastUsed)
+	switch {
+	case days > unusedDays:
+		return fmt.Sprintf("unused %dd", days)
+	case days == 0:
+		return "today"
+	default:
+		return fmt.Sprintf("%dd ago", days)
+	}
+}
+
+// permissionUsage returns when the role last used any of the actions a
+// permission grants. known is false when the last-accessed data covers none
+// of the services the permission grants actions of.
+func permissionUsage(p types.PermissionDisplay, usage []types.ServiceUsage) (lastUsed time.Time, known bool) {
+	for _, s := range usage {
+		if !grantsService(p, s.Service) {
+			continue
+		}
+		known = true
+		if used := serviceLastUsed(p, s); used.After(lastUsed) {
+			lastUsed = used
+		}
+	}
+	return lastUsed, known
+}
+
+// grantsService reports whether a permission may grant actions of a service
+func grantsService(p types.PermissionDisplay, service string) bool {
+	if p.NotAction {
+		// Only excluding the whole service takes all of its actions away
+		return !evaluator.MatchAction(p.Action, service+":*")
+	}
+	return evaluator.OverlapAction(p.Action, service+":*")
+}
+
+// serviceLastUsed returns when the role last used the actions of a service
+// a permission grants. IAM tracks the actions of a few services
+// individually; when it tracks every action the permission grants, their
+// latest use is returned, and otherwise the last use of the service as a
+// whole.
+func serviceLastUsed(p types.PermissionDisplay, s types.ServiceUsage) time.Time {
+	if len(s.Actions) == 0 || p.NotAction {
+		return s.LastUsed
+	}
+
+	tracked := make(map[string]time.Time, len(s.Actions))
+	for _, a := range s.Actions {
+		tracked[strings.ToLower(a.Action)] = a.LastUsed
+	}
+
+	granted := []string{p.Action}
+	if strings.ContainsAny(p.Action, "*?") {
+		actions, known := catalog.Default().Expand(p.Action)
+		if !known {
+			return s.LastUsed
+		}
+		granted = granted[:0]
+		for _, a := range actions {
+			granted = append(granted, a.Name)
+		}
+	}
+
+	var lastUsed time.Time
+	for _, action := range granted {
+		used, ok := tracked[strings.ToLower(action)]
+		if !ok {
+			return s.LastUsed
+		}
+		if used.After(lastUsed) {
+			lastUsed = used
+		}
+	}
+	return lastUsed
+}
+
+// daysSince counts the whole days between t and now
+func daysSince(t time.Time) int {
+	return int(now().Sub(t).Hours() / 24)
+}
+
+// printUsageSummary counts the grants of the reported pods that went unused
+// for longer than unusedDays, the candidates for removal in an access review
+func printUsageSummary(perms []types.PodPermissions, unusedDays int) {
+	total, unused := 0, 0
+	for _, perm := range perms {
+		for _, policy := range perm.Policies {
+			for _, p := range policy.Permissions {
+				if !grants(p) {
+					continue
+				}
+				lastUsed, known := permissionUsage(p, perm.Usage)
+				if !known {
+					continue
+				}
+				total++
+				if lastUsed.IsZero() || daysSince(lastUsed) > unusedDays {
+					unused++
+				}
+			}
+		}
+	}
+
+	switch {
+	case total == 0:
+		fmt.Println("No IAM last-accessed data for these permissions")
+	case unused == 0:
+		fmt.Printf("%s Every permission was used in the last %d days\n", checkmark, unusedDays)
+	default:
+		fmt.Printf("%s %d of %d permissions were not used in the last %d days\n", warning, unused, total, unusedDays)
+	}
+}
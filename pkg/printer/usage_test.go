@@ -0,0 +1,127 @@
+package printer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageCell(t *testing.T) {
+	today := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return today }
+
+	usage := []types.ServiceUsage{
+		{
+			Service:  "iam",
+			LastUsed: today.AddDate(0, 0, -3),
+			Actions: []types.ActionUsage{
+				{Action: "iam:GetRole", LastUsed: today.AddDate(0, 0, -3)},
+				{Action: "iam:CreateRole"},
+			},
+		},
+		{
+			Service:  "sqs",
+			LastUsed: today.AddDate(0, 0, -2),
+			Actions: []types.ActionUsage{
+				{Action: "sqs:PurgeQueue", LastUsed: today.AddDate(0, 0, -120)},
+			},
+		},
+		{Service: "s3", LastUsed: today.Add(-time.Hour)},
+		{Service: "kms"},
+	}
+
+	tests := []struct {
+		name     string
+		perm     types.PermissionDisplay
+		expected string
+	}{
+		{
+			name:     "tracked action used recently",
+			perm:     types.PermissionDisplay{Action: "iam:GetRole", Resource: "*", Effect: "Allow"},
+			expected: "3d ago",
+		},
+		{
+			name:     "tracked action never used",
+			perm:     types.PermissionDisplay{Action: "iam:CreateRole", Resource: "*", Effect: "Allow"},
+			expected: "never used",
+		},
+		{
+			name:     "wildcard of tracked actions",
+			perm:     types.PermissionDisplay{Action: "sqs:PurgeQ*", Resource: "*", Effect: "Allow"},
+			expected: "unused 120d",
+		},
+		{
+			name:     "untracked action falls back to its service",
+			perm:     types.PermissionDisplay{Action: "sqs:SendMessage", Resource: "*", Effect: "Allow"},
+			expected: "2d ago",
+		},
+		{
+			name:     "service used today",
+			perm:     types.PermissionDisplay{Action: "s3:GetObject", Resource: "*", Effect: "Allow"},
+			expected: "today",
+		},
+		{
+			name:     "service never used",
+			perm:     types.PermissionDisplay{Action: "kms:Decrypt", Resource: "*", Effect: "Allow"},
+			expected: "never used",
+		},
+		{
+			name:     "full wildcard takes the latest use of any service",
+			perm:     types.PermissionDisplay{Action: "*", Resource: "*", Effect: "Allow"},
+			expected: "today",
+		},
+		{
+			name:     "NotAction excluding the used services",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", NotAction: true},
+			expected: "2d ago",
+		},
+		{
+			name:     "no last-accessed data",
+			perm:     types.PermissionDisplay{Action: "dynamodb:GetItem", Resource: "*", Effect: "Allow"},
+			expected: "-",
+		},
+		{
+			name:     "deny",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Deny"},
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, usageCell(tt.perm, usage, 90))
+		})
+	}
+}
+
+func TestUsageCell_Window(t *testing.T) {
+	today := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	defer func(f func() time.Time) { now = f }(now)
+	now = func() time.Time { return today }
+
+	perm := types.PermissionDisplay{Action: "s3:GetObject", Resource: "*", Effect: "Allow"}
+
+	tests := []struct {
+		name       string
+		daysAgo    int
+		unusedDays int
+		expected   string
+	}{
+		{name: "used on the last day of the window", daysAgo: 90, unusedDays: 90, expected: "90d ago"},
+		{name: "used the day before the window", daysAgo: 91, unusedDays: 90, expected: "unused 91d"},
+		{name: "shorter window", daysAgo: 31, unusedDays: 30, expected: "unused 31d"},
+		{name: "longer window", daysAgo: 120, unusedDays: 180, expected: "120d ago"},
+		{name: "empty window", daysAgo: 1, unusedDays: 0, expected: "unused 1d"},
+		{name: "used today with an empty window", daysAgo: 0, unusedDays: 0, expected: "today"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := []types.ServiceUsage{{Service: "s3", LastUsed: today.AddDate(0, 0, -tt.daysAgo)}}
+			assert.Equal(t, tt.expected, usageCell(perm, usage, tt.unusedDays))
+		})
+	}
+}
@@ -0,0 +1,278 @@
+// Package risk scores how much harm the permissions of a role could do in
+// the wrong hands, from 0 to 100, and rates scores by severity. A score
+// weighs how sensitive the granted actions are, how many resources they reach
+// and whether those may belong to other accounts, and is lowered by
+// conditions that are not known to hold. Risk rules raise the scores of the
+// permissions they flag.
+package risk
+
+import (
+	"strings"
+
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/types"
+)
+
+// Severities of a score
+const (
+	SeverityLow      = "Low"
+	SeverityMedium   = "Medium"
+	SeverityHigh     = "High"
+	SeverityCritical = "Critical"
+)
+
+// Lowest scores of each severity above Low
+const (
+	MediumScore   = 30
+	HighScore     = 55
+	CriticalScore = 80
+)
+
+// Scores of a role that can escalate its privileges, whatever its
+// permissions score on their own
+const (
+	escalationScore            = 90
+	conditionalEscalationScore = 60
+)
+
+// accessLevelWeights weighs actions by the access level AWS assigns them.
+// Actions missing from the action catalog are weighed by their verb, see
+// guessAccessLevel.
+var accessLevelWeights = map[string]int{
+	catalog.AccessList:        5,
+	catalog.AccessRead:        10,
+	catalog.AccessTagging:     15,
+	catalog.AccessWrite:       25,
+	catalog.AccessPermissions: 40,
+}
+
+// Weights of how far a permission reaches beyond the actions and resources
+// it names
+const (
+	severalActionsWeight = 10 // A wildcard matching several actions of a service
+	allActionsWeight     = 30 // * or NotAction, granting actions of every service
+	allResourcesWeight   = 20 // * or NotResource
+	someResourcesWeight  = 10 // A wildcard matching several resources
+	crossAccountWeight   = 15 // Resources of any account, or of another one
+)
+
+// sensitiveServices are the services whose actions control identities,
+// credentials, keys and secrets, adding sensitiveServiceWeight
+var sensitiveServices = []string{"iam", "sts", "kms", "secretsmanager", "organizations"}
+
+const sensitiveServiceWeight = 25
+
+// Score rates a permission of a role in account, the 12-digit account ID, or
+// "" when it is unknown. Denies, Allows an explicit Deny fully overrides and
+// permissions whose conditions fail in the request context grant nothing and
+// score 0. A permission matching any of the rules scores at least as much as
+// the severity of the rule, before conditions not known to hold halve the
+// score and a partial Deny takes a quarter off.
+func Score(p types.PermissionDisplay, account string, rules []Rule) int {
+	if !grants(p) {
+		return 0
+	}
+
+	score := actionWeight(p) + resourceWeight(p) + reachWeight(p, account)
+	if floor := ruleFloor(p, rules); floor > score {
+		score = floor
+	}
+	if p.IsConditional() {
+		score /= 2
+	}
+	if p.Denial == types.DenialPartial {
+		score = score * 3 / 4
+	}
+	if score > 100 {
+		score = 100
+	}
+	return score
+}
+
+// Severity rates a score
+func Severity(score int) string {
+	switch {
+	case score >= CriticalScore:
+		return SeverityCritical
+	case score >= HighScore:
+		return SeverityHigh
+	case score >= MediumScore:
+		return SeverityMedium
+	default:
+		return SeverityLow
+	}
+}
+
+// ScorePolicies returns a copy of the policies in which every permission is
+// scored, names the rules it matches, is marked broad when it grants several
+// actions or resources and high risk when it scores High or above, and every
+// policy is scored by its riskiest permission
+func ScorePolicies(policies []types.Policy, account string, rules []Rule) []types.Policy {
+	if policies == nil {
+		return nil
+	}
+
+	scored := make([]types.Policy, len(policies))
+	for i, policy := range policies {
+		scored[i] = policy
+		scored[i].Score = 0
+		if policy.Permissions == nil {
+			continue
+		}
+
+		perms := make([]types.PermissionDisplay, len(policy.Permissions))
+		for j, p := range policy.Permissions {
+			p.Score = Score(p, account, rules)
+			p.IsBroad = isBroad(p)
+			p.IsHighRisk = p.Score >= HighScore
+			p.RiskRules = nil
+			if grants(p) {
+				for _, r := range MatchRules(p, rules) {
+					p.RiskRules = append(p.RiskRules, r.Description)
+				}
+			}
+			if p.Score > scored[i].Score {
+				scored[i].Score = p.Score
+			}
+			perms[j] = p
+		}
+		scored[i].Permissions = perms
+	}
+	return scored
+}
+
+// PodScore rates a pod by the riskiest of the scored policies in effect for
+// its role. A role that can escalate its privileges may obtain any
+// permission, so it scores as Critical, or as High when that depends on
+// conditions.
+func PodScore(policies []types.Policy, paths []types.EscalationPath) int {
+	score := 0
+	for _, policy := range policies {
+		if policy.Score > score {
+			score = policy.Score
+		}
+	}
+	for _, path := range paths {
+		floor := escalationScore
+		if path.Conditional {
+			floor = conditionalEscalationScore
+		}
+		if floor > score {
+			score = floor
+		}
+	}
+	return score
+}
+
+// AccountID returns the account of an ARN, or "" when it has none, as with
+// S3 buckets, or is not an ARN
+func AccountID(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[0] != "arn" {
+		return ""
+	}
+	return parts[4]
+}
+
+// grants reports whether a permission is an Allow that takes effect
+func grants(p types.PermissionDisplay) bool {
+	return p.Effect == "Allow" && p.Denial != types.DenialFull && !p.IsInapplicable()
+}
+
+// actionWeight weighs the most sensitive action a permission grants, and how
+// many it grants
+func actionWeight(p types.PermissionDisplay) int {
+	if p.NotAction || !strings.Contains(p.Action, ":") {
+		// Every service, or every one but a few
+		return accessLevelWeights[catalog.AccessPermissions] + allActionsWeight + sensitiveServiceWeight
+	}
+
+	weight := 0
+	if strings.ContainsAny(p.Action, "*?") {
+		actions, known := catalog.Default().Expand(p.Action)
+		if !known {
+			weight = accessLevelWeights[guessAccessLevel(p.Action)] + severalActionsWeight
+		} else {
+			for _, a := range actions {
+				if w := accessLevelWeights[a.AccessLevel]; w > weight {
+					weight = w
+				}
+			}
+			if len(actions) > 1 {
+				weight += severalActionsWeight
+			}
+		}
+	} else if a, ok := catalog.Default().Lookup(p.Action); ok {
+		weight = accessLevelWeights[a.AccessLevel]
+	} else {
+		weight = accessLevelWeights[guessAccessLevel(p.Action)]
+	}
+
+	for _, service := range sensitiveServices {
+		if evaluator.OverlapAction(p.Action, service+":*") {
+			weight += sensitiveServiceWeight
+			break
+		}
+	}
+	return weight
+}
+
+// guessAccessLevel classifies an action, or action pattern, missing from the
+// action catalog by the verb it starts with. AWS names read-only actions
+// List, Describe or Get; any other verb, or a pattern starting with a
+// wildcard, may write.
+func guessAccessLevel(action string) string {
+	_, name, _ := strings.Cut(action, ":")
+	switch {
+	case strings.HasPrefix(name, "List"), strings.HasPrefix(name, "Describe"):
+		return catalog.AccessList
+	case strings.HasPrefix(name, "Get"), strings.HasPrefix(name, "BatchGet"):
+		return catalog.AccessRead
+	default:
+		return catalog.AccessWrite
+	}
+}
+
+// isBroad reports whether a permission grants more than one action or
+// reaches more than one resource. Allowing everything but a few actions or
+// resources grants far more than it names; as a Deny it is a guardrail
+// instead.
+func isBroad(p types.PermissionDisplay) bool {
+	if p.Effect == "Allow" && (p.NotAction || p.NotResource) {
+		return true
+	}
+	return catalog.Default().IsBroad(p.Action) || strings.Contains(p.Resource, "*")
+}
+
+// resourceWeight weighs how many resources a permission reaches
+func resourceWeight(p types.PermissionDisplay) int {
+	switch {
+	case p.NotResource || p.Resource == "*":
+		return allResourcesWeight
+	case strings.ContainsAny(p.Resource, "*?"):
+		return someResourcesWeight
+	default:
+		return 0
+	}
+}
+
+// reachWeight weighs whether a permission reaches resources of accounts
+// other than the role's own. Resources without an account, such as S3
+// buckets, and * are weighed by their scope alone.
+func reachWeight(p types.PermissionDisplay, account string) int {
+	if p.NotResource || p.Resource == "*" {
+		return 0
+	}
+	resourceAccount := AccountID(p.Resource)
+	switch {
+	case resourceAccount == "":
+		return 0
+	case strings.ContainsAny(resourceAccount, "*?"):
+		return crossAccountWeight
+	case account != "" && resourceAccount != account:
+		return crossAccountWeight
+	default:
+		return 0
+	}
+}
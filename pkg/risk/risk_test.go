@@ -0,0 +1,214 @@
+package risk
+
+import (
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+	const account = "123456789012"
+	vpc := []types.Condition{{Operator: "StringEquals", Key: "aws:SourceVpc", Values: []string{"vpc-123"}}}
+
+	tests := []struct {
+		name     string
+		perm     types.PermissionDisplay
+		expected int
+		severity string
+	}{
+		{
+			name:     "read one object",
+			perm:     types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a.csv", Effect: "Allow"},
+			expected: 10,
+			severity: SeverityLow,
+		},
+		{
+			name:     "read every object of a bucket",
+			perm:     types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"},
+			expected: 20,
+			severity: SeverityLow,
+		},
+		{
+			name:     "write every resource",
+			perm:     types.PermissionDisplay{Action: "dynamodb:PutItem", Resource: "*", Effect: "Allow"},
+			expected: 45,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "every action of a service",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow"},
+			expected: 70,
+			severity: SeverityHigh,
+		},
+		{
+			name:     "sensitive service",
+			perm:     types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Allow"},
+			expected: 95,
+			severity: SeverityCritical,
+		},
+		{
+			name:     "administrator",
+			perm:     types.PermissionDisplay{Action: "*", Resource: "*", Effect: "Allow"},
+			expected: 100,
+			severity: SeverityCritical,
+		},
+		{
+			name:     "NotAction",
+			perm:     types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Allow", NotAction: true},
+			expected: 100,
+			severity: SeverityCritical,
+		},
+		{
+			name:     "secret of another account",
+			perm:     types.PermissionDisplay{Action: "secretsmanager:GetSecretValue", Resource: "arn:aws:secretsmanager:us-east-1:999999999999:secret:db", Effect: "Allow"},
+			expected: 50,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "secret of the role's account",
+			perm:     types.PermissionDisplay{Action: "secretsmanager:GetSecretValue", Resource: "arn:aws:secretsmanager:us-east-1:123456789012:secret:db", Effect: "Allow"},
+			expected: 35,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "queues of any account",
+			perm:     types.PermissionDisplay{Action: "sqs:SendMessage", Resource: "arn:aws:sqs:us-east-1:*:jobs", Effect: "Allow"},
+			expected: 50,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "unknown action",
+			perm:     types.PermissionDisplay{Action: "newservice:DoThing", Resource: "*", Effect: "Allow"},
+			expected: 45,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "unknown read action",
+			perm:     types.PermissionDisplay{Action: "newservice:GetThing", Resource: "*", Effect: "Allow"},
+			expected: 30,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "unknown read-only wildcard",
+			perm:     types.PermissionDisplay{Action: "newservice:Describe*", Resource: "*", Effect: "Allow"},
+			expected: 35,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "every action of an unknown service",
+			perm:     types.PermissionDisplay{Action: "newservice:*", Resource: "*", Effect: "Allow"},
+			expected: 55,
+			severity: SeverityHigh,
+		},
+		{
+			name:     "conditional",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: vpc},
+			expected: 35,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "conditions hold in the given context",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: vpc, ConditionResult: types.ConditionsHold},
+			expected: 70,
+			severity: SeverityHigh,
+		},
+		{
+			name:     "conditions fail in the given context",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", HasCondition: true, Conditions: vpc, ConditionResult: types.ConditionsFail},
+			expected: 0,
+			severity: SeverityLow,
+		},
+		{
+			name:     "partially denied",
+			perm:     types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow", Denial: types.DenialPartial},
+			expected: 52,
+			severity: SeverityMedium,
+		},
+		{
+			name:     "fully denied",
+			perm:     types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Allow", Denial: types.DenialFull},
+			expected: 0,
+			severity: SeverityLow,
+		},
+		{
+			name:     "deny statement",
+			perm:     types.PermissionDisplay{Action: "*", Resource: "*", Effect: "Deny"},
+			expected: 0,
+			severity: SeverityLow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := Score(tt.perm, account, nil)
+			assert.Equal(t, tt.expected, score)
+			assert.Equal(t, tt.severity, Severity(score))
+		})
+	}
+}
+
+func TestScorePolicies(t *testing.T) {
+	policies := []types.Policy{
+		{
+			Name: "app",
+			Permissions: []types.PermissionDisplay{
+				{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a.csv", Effect: "Allow"},
+				{Action: "s3:*", Resource: "*", Effect: "Allow"},
+			},
+		},
+		{Name: "empty"},
+	}
+
+	scored := ScorePolicies(policies, "", nil)
+	assert.Equal(t, 10, scored[0].Permissions[0].Score)
+	assert.Equal(t, 70, scored[0].Permissions[1].Score)
+	assert.Equal(t, 70, scored[0].Score)
+	assert.Equal(t, 0, scored[1].Score)
+
+	// Flags are derived from the score and the actions and resources granted
+	assert.False(t, scored[0].Permissions[0].IsBroad)
+	assert.False(t, scored[0].Permissions[0].IsHighRisk)
+	assert.True(t, scored[0].Permissions[1].IsBroad)
+	assert.True(t, scored[0].Permissions[1].IsHighRisk)
+
+	// The policies given are left unscored
+	assert.Equal(t, 0, policies[0].Permissions[1].Score)
+}
+
+func TestIsBroad(t *testing.T) {
+	tests := []struct {
+		name     string
+		perm     types.PermissionDisplay
+		expected bool
+	}{
+		{name: "one action on one resource", perm: types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/a.csv", Effect: "Allow"}},
+		{name: "wildcard resource", perm: types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports/*", Effect: "Allow"}, expected: true},
+		{name: "wildcard matching a single action", perm: types.PermissionDisplay{Action: "sqs:PurgeQ*", Resource: "arn:aws:sqs:us-east-1:123456789012:jobs", Effect: "Allow"}},
+		{name: "wildcard matching several actions", perm: types.PermissionDisplay{Action: "sqs:*Message", Resource: "arn:aws:sqs:us-east-1:123456789012:jobs", Effect: "Allow"}, expected: true},
+		{name: "allow with NotResource", perm: types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::payroll/data", Effect: "Allow", NotResource: true}, expected: true},
+		{name: "deny with NotAction", perm: types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::reports", Effect: "Deny", NotAction: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isBroad(tt.perm))
+		})
+	}
+}
+
+func TestPodScore(t *testing.T) {
+	policies := []types.Policy{{Name: "read", Score: 20}, {Name: "write", Score: 45}}
+
+	assert.Equal(t, 45, PodScore(policies, nil))
+	assert.Equal(t, 60, PodScore(policies, []types.EscalationPath{{Path: "ssm:SendCommand", Conditional: true}}))
+	assert.Equal(t, 90, PodScore(policies, []types.EscalationPath{{Path: "ssm:SendCommand"}}))
+	assert.Equal(t, 100, PodScore([]types.Policy{{Name: "admin", Score: 100}}, []types.EscalationPath{{Path: "ssm:SendCommand"}}))
+	assert.Equal(t, 0, PodScore(nil, nil))
+}
+
+func TestAccountID(t *testing.T) {
+	assert.Equal(t, "123456789012", AccountID("arn:aws:iam::123456789012:role/api"))
+	assert.Equal(t, "", AccountID("arn:aws:s3:::reports/a.csv"))
+	assert.Equal(t, "", AccountID("api"))
+}
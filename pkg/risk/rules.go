@@ -0,0 +1,146 @@
+package risk
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/berkguzel/pperm/pkg/evaluator"
+	"github.com/berkguzel/pperm/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// Rule flags the permissions granting an action matching Action on every
+// resource matching Resource as risky. A permission matching a rule scores
+// at least as much as the lowest score of its severity.
+type Rule struct {
+	Action string `json:"action"` // Action glob, e.g. iam:* or s3:Delete*
+	// Full restricts the rule to permissions granting every action matching
+	// Action, such as s3:* for the rule s3:*, rather than any of them
+	Full bool `json:"full,omitempty"`
+	// Resource is a glob the permission must cover, e.g. * to flag only
+	// grants on every resource. Empty when a grant on any resource is risky.
+	Resource    string `json:"resource,omitempty"`
+	Severity    string `json:"severity"` // SeverityLow to SeverityCritical
+	Description string `json:"description"`
+}
+
+// RulesFile is the format of a risk rules file, in YAML or JSON
+type RulesFile struct {
+	// ReplaceDefaults drops DefaultRules instead of adding Rules to them
+	ReplaceDefaults bool   `json:"replaceDefaults,omitempty"`
+	Rules           []Rule `json:"rules"`
+}
+
+// DefaultRules are the risk rules applied when no rules file replaces them.
+// Any action of the services controlling identities, keys and secrets is
+// risky, while data and compute services are when fully granted on every
+// resource.
+var DefaultRules = []Rule{
+	{Action: "iam:*", Severity: SeverityHigh, Description: "IAM access, which can change what any principal may do"},
+	{Action: "kms:*", Severity: SeverityHigh, Description: "KMS access, which can decrypt data or make it unreadable"},
+	{Action: "secretsmanager:*", Severity: SeverityHigh, Description: "Secrets Manager access, which can expose credentials"},
+	{Action: "s3:*", Full: true, Resource: "*", Severity: SeverityHigh, Description: "Full S3 access"},
+	{Action: "dynamodb:*", Full: true, Resource: "*", Severity: SeverityHigh, Description: "Full DynamoDB access"},
+	{Action: "rds:*", Full: true, Resource: "*", Severity: SeverityHigh, Description: "Full RDS access"},
+	{Action: "ec2:*", Full: true, Resource: "*", Severity: SeverityHigh, Description: "Full EC2 access"},
+	{Action: "lambda:*", Full: true, Resource: "*", Severity: SeverityHigh, Description: "Full Lambda access"},
+}
+
+// severityScores are the lowest scores of each severity
+var severityScores = map[string]int{
+	SeverityLow:      0,
+	SeverityMedium:   MediumScore,
+	SeverityHigh:     HighScore,
+	SeverityCritical: CriticalScore,
+}
+
+// LoadRules returns the risk rules of a file, added to DefaultRules unless the
+// file replaces them, or DefaultRules when path is empty
+func LoadRules(path string) ([]Rule, error) {
+	if path == "" {
+		return DefaultRules, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk rules: %v", err)
+	}
+
+	var file RulesFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse risk rules %s: %v", path, err)
+	}
+
+	for i := range file.Rules {
+		if err := file.Rules[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid risk rule %d in %s: %v", i+1, path, err)
+		}
+	}
+
+	if file.ReplaceDefaults {
+		return file.Rules, nil
+	}
+	rules := make([]Rule, 0, len(DefaultRules)+len(file.Rules))
+	rules = append(rules, DefaultRules...)
+	return append(rules, file.Rules...), nil
+}
+
+// validate checks a rule read from a file, normalizing the case of its
+// severity
+func (r *Rule) validate() error {
+	if r.Action == "" {
+		return fmt.Errorf("action is required")
+	}
+	if r.Action != "*" && !strings.Contains(r.Action, ":") {
+		return fmt.Errorf("action %q is not of the form service:action", r.Action)
+	}
+	for severity := range severityScores {
+		if strings.EqualFold(r.Severity, severity) {
+			r.Severity = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("severity %q is not one of Low, Medium, High or Critical", r.Severity)
+}
+
+// Matches reports whether a statement grants, or denies, the actions the
+// rule flags on the resources it flags
+func (r Rule) Matches(p types.PermissionDisplay) bool {
+	actions := types.PermissionDisplay{Action: p.Action, NotAction: p.NotAction, Resource: "*"}
+	flagged := types.PermissionDisplay{Action: r.Action, Resource: "*"}
+	if r.Full && !evaluator.Covers(actions, flagged) {
+		return false
+	}
+	if !r.Full && !evaluator.Overlaps(actions, flagged) {
+		return false
+	}
+
+	if r.Resource == "" {
+		return true
+	}
+	resources := types.PermissionDisplay{Action: "*", Resource: p.Resource, NotResource: p.NotResource}
+	return evaluator.Covers(resources, types.PermissionDisplay{Action: "*", Resource: r.Resource})
+}
+
+// MatchRules returns the rules a statement matches
+func MatchRules(p types.PermissionDisplay, rules []Rule) []Rule {
+	var matched []Rule
+	for _, r := range rules {
+		if r.Matches(p) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+// ruleFloor is the lowest score the rules a permission matches allow it
+func ruleFloor(p types.PermissionDisplay, rules []Rule) int {
+	floor := 0
+	for _, r := range MatchRules(p, rules) {
+		if s := severityScores[r.Severity]; s > floor {
+			floor = s
+		}
+	}
+	return floor
+}
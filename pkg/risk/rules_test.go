@@ -0,0 +1,164 @@
+package risk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/berkguzel/pperm/pkg/catalog"
+	"github.com/berkguzel/pperm/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	rules, err := LoadRules("")
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultRules, rules)
+
+	rules, err = LoadRules(write("rules.yaml", `
+rules:
+  - action: s3:DeleteBucket
+    severity: critical
+    description: Deletes buckets
+  - action: sqs:*
+    full: true
+    resource: "*"
+    severity: Medium
+    description: Full SQS access
+`))
+	assert.NoError(t, err)
+	assert.Len(t, rules, len(DefaultRules)+2)
+	assert.Equal(t, DefaultRules, rules[:len(DefaultRules)])
+	assert.Equal(t, []Rule{
+		{Action: "s3:DeleteBucket", Severity: SeverityCritical, Description: "Deletes buckets"},
+		{Action: "sqs:*", Full: true, Resource: "*", Severity: SeverityMedium, Description: "Full SQS access"},
+	}, rules[len(DefaultRules):])
+
+	rules, err = LoadRules(write("rules.json", `{"replaceDefaults": true, "rules": [{"action": "iam:PassRole", "severity": "High", "description": "Passes roles"}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Rule{{Action: "iam:PassRole", Severity: SeverityHigh, Description: "Passes roles"}}, rules)
+
+	_, err = LoadRules(write("severity.yaml", "rules:\n  - action: iam:*\n    severity: Severe\n"))
+	assert.ErrorContains(t, err, `invalid risk rule 1`)
+
+	_, err = LoadRules(write("action.yaml", "rules:\n  - action: PassRole\n    severity: High\n"))
+	assert.ErrorContains(t, err, "service:action")
+
+	_, err = LoadRules(write("typo.yaml", "rules:\n  - actions: iam:*\n    severity: High\n"))
+	assert.ErrorContains(t, err, "failed to parse risk rules")
+
+	_, err = LoadRules(filepath.Join(dir, "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read risk rules")
+}
+
+func TestRule_Matches(t *testing.T) {
+	anyAction := Rule{Action: "iam:*", Severity: SeverityHigh}
+	full := Rule{Action: "s3:*", Full: true, Resource: "*", Severity: SeverityHigh}
+	bucket := Rule{Action: "s3:Delete*", Resource: "arn:aws:s3:::reports*", Severity: SeverityHigh}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		perm     types.PermissionDisplay
+		expected bool
+	}{
+		{"any action of a service", anyAction, types.PermissionDisplay{Action: "iam:GetRole", Resource: "arn:aws:iam::123456789012:role/api"}, true},
+		{"wildcard overlapping the rule", anyAction, types.PermissionDisplay{Action: "*", Resource: "*"}, true},
+		{"another service", anyAction, types.PermissionDisplay{Action: "s3:GetObject", Resource: "*"}, false},
+		{"NotAction excluding the service", anyAction, types.PermissionDisplay{Action: "iam:*", Resource: "*", NotAction: true}, false},
+		{"full access", full, types.PermissionDisplay{Action: "s3:*", Resource: "*"}, true},
+		{"every action", full, types.PermissionDisplay{Action: "*", Resource: "*"}, true},
+		{"some actions only", full, types.PermissionDisplay{Action: "s3:GetObject", Resource: "*"}, false},
+		{"full access to a single bucket", full, types.PermissionDisplay{Action: "s3:*", Resource: "arn:aws:s3:::reports/*"}, false},
+		{"covering the resources", bucket, types.PermissionDisplay{Action: "s3:DeleteObject", Resource: "arn:aws:s3:::reports*"}, true},
+		{"some of the resources only", bucket, types.PermissionDisplay{Action: "s3:DeleteObject", Resource: "arn:aws:s3:::reports/2024/*"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.rule.Matches(tt.perm))
+		})
+	}
+}
+
+func TestScorePolicies_IsHighRisk(t *testing.T) {
+	allow := func(action, resource string) types.PermissionDisplay {
+		return types.PermissionDisplay{Action: action, Resource: resource, Effect: "Allow"}
+	}
+	sendRule := []Rule{{Action: "sqs:SendMessage", Severity: SeverityHigh, Description: "Sends jobs"}}
+
+	tests := []struct {
+		name     string
+		perm     types.PermissionDisplay
+		rules    []Rule
+		expected bool
+	}{
+		{name: "IAM on a single role", perm: allow("iam:*", "arn:aws:iam::123456789012:role/specific-role"), rules: DefaultRules, expected: true},
+		{name: "IAM write on every resource", perm: allow("iam:CreateRole", "*"), rules: DefaultRules, expected: true},
+		{name: "KMS write on every resource", perm: allow("kms:CreateKey", "*"), rules: DefaultRules, expected: true},
+		{name: "full Lambda access", perm: allow("lambda:*", "*"), rules: DefaultRules, expected: true},
+		{name: "single Lambda write", perm: allow("lambda:InvokeFunction", "*"), rules: DefaultRules, expected: false},
+		{name: "full S3 access", perm: allow("s3:*", "*"), rules: DefaultRules, expected: true},
+		{name: "every S3 action on one bucket", perm: allow("s3:*", "arn:aws:s3:::my-bucket/*"), rules: DefaultRules, expected: true},
+		{name: "S3 read on every object", perm: allow("s3:GetObject", "*"), rules: DefaultRules, expected: false},
+		{name: "EC2 read-only wildcard", perm: allow("ec2:Describe*", "*"), rules: DefaultRules, expected: false},
+		{
+			name:     "NotAction allow",
+			perm:     types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Allow", NotAction: true},
+			rules:    DefaultRules,
+			expected: true,
+		},
+		{name: "deny", perm: types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Deny"}, rules: DefaultRules, expected: false},
+		{
+			name:     "conditions not known to hold",
+			perm:     types.PermissionDisplay{Action: "iam:*", Resource: "*", Effect: "Allow", HasCondition: true},
+			rules:    DefaultRules,
+			expected: false,
+		},
+		{name: "action no rule flags", perm: allow("sqs:SendMessage", "arn:aws:sqs:us-east-1:123456789012:jobs"), rules: DefaultRules, expected: false},
+		{name: "action a custom rule flags", perm: allow("sqs:SendMessage", "arn:aws:sqs:us-east-1:123456789012:jobs"), rules: sendRule, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scored := ScorePolicies([]types.Policy{{Name: "policy", Permissions: []types.PermissionDisplay{tt.perm}}}, "123456789012", tt.rules)
+			assert.Equal(t, tt.expected, scored[0].Permissions[0].IsHighRisk)
+		})
+	}
+}
+
+func TestScore_Rules(t *testing.T) {
+	read := types.PermissionDisplay{Action: "s3:GetObject", Resource: "arn:aws:s3:::payroll/*", Effect: "Allow"}
+	rules := []Rule{{Action: "s3:GetObject", Resource: "arn:aws:s3:::payroll/*", Severity: SeverityCritical, Description: "Reads payroll"}}
+
+	assert.Equal(t, 20, Score(read, "", nil))
+	assert.Equal(t, CriticalScore, Score(read, "", rules))
+
+	// Rules raise scores only
+	assert.Equal(t, 70, Score(types.PermissionDisplay{Action: "s3:*", Resource: "*", Effect: "Allow"}, "", []Rule{{Action: "s3:*", Severity: SeverityLow}}))
+
+	// Conditions lower the raised score
+	read.HasCondition = true
+	assert.Equal(t, CriticalScore/2, Score(read, "", rules))
+
+	scored := ScorePolicies([]types.Policy{{Name: "payroll", Permissions: []types.PermissionDisplay{read}}}, "", rules)
+	assert.Equal(t, []string{"Reads payroll"}, scored[0].Permissions[0].RiskRules)
+}
+
+func TestDefaultRules_Catalogued(t *testing.T) {
+	// Grants of the services the rules single out are scored by the access
+	// levels of their actions, which the action catalog provides
+	services := catalog.Default().Services()
+	for _, rule := range DefaultRules {
+		service, _, _ := strings.Cut(rule.Action, ":")
+		assert.Contains(t, services, service, rule.Action)
+	}
+}
@@ -2,6 +2,36 @@ package types
 
 import (
 	"fmt"
+	"strings"
+	"time"
+)
+
+// Mechanisms through which a pod obtains its IAM role
+const (
+	CredentialSourceIRSA        = "IRSA"
+	CredentialSourcePodIdentity = "EKS Pod Identity"
+	CredentialSourceKube2iam    = "kube2iam/kiam"
+	CredentialSourceNode        = "node credentials"
+)
+
+// Ways a policy can be associated with an IAM role
+const (
+	PolicyTypeManaged  = "managed"
+	PolicyTypeInline   = "inline"
+	PolicyTypeBoundary = "boundary"
+)
+
+// How an explicit Deny overrides an Allow permission
+const (
+	DenialFull    = "denied"
+	DenialPartial = "partially denied"
+)
+
+// Whether a permission's conditions hold in the request context given on
+// the command line
+const (
+	ConditionsHold = "hold"
+	ConditionsFail = "fail"
 )
 
 type Permission struct {
@@ -13,37 +43,155 @@ type Permission struct {
 }
 
 type PermissionDisplay struct {
-	Action       string
-	Resource     string
-	Effect       string
-	IsBroad      bool
-	IsHighRisk   bool
-	HasCondition bool
+	Action          string
+	Resource        string
+	Effect          string
+	IsBroad         bool
+	IsHighRisk      bool
+	HasCondition    bool
+	Conditions      []Condition // Tests of the statement's Condition block, all of which must hold
+	ConditionResult string      // ConditionsHold or ConditionsFail once Conditions were evaluated against a request context
+	NotAction       bool        // Applies to every action except Action
+	NotResource     bool        // Applies to every resource except Resource
+	Denial          string      // DenialFull or DenialPartial when an explicit Deny overrides this Allow
+	DeniedBy        string      // Policy holding that Deny
+	Score           int         // Risk score from 0 to 100, see package risk
+	RiskRules       []string    // Descriptions of the risk rules it matches
+}
+
+// Condition is a single test of a statement's Condition block, such as
+// StringEquals on aws:SourceVpce. The test holds when the key matches any of
+// the values.
+type Condition struct {
+	Operator string // e.g. StringEquals, ForAnyValue:StringLike, ArnLikeIfExists
+	Key      string
+	Values   []string
+}
+
+func (c Condition) String() string {
+	values := strings.Join(c.Values, ", ")
+	if len(c.Values) > 1 {
+		values = "[" + values + "]"
+	}
+	return fmt.Sprintf("%s %s %s", c.Key, c.Operator, values)
 }
 
 type Policy struct {
 	Name        string
-	Arn         string
+	Arn         string // Empty for inline policies
+	Type        string // PolicyTypeManaged or PolicyTypeInline
 	Permissions []PermissionDisplay
+	Score       int // Risk score of its riskiest permission
 }
 
 type PodPermissions struct {
-	PodName        string
-	Namespace      string
-	ServiceAccount string
-	IAMRole        string
-	Policies       []Policy
+	PodName             string
+	Workload            string // KIND/NAME when analyzed by workload reference
+	Namespace           string
+	ServiceAccount      string
+	IAMRole             string
+	CredentialSource    string // Mechanism that granted IAMRole, e.g. IRSA
+	Policies            []Policy
+	PermissionsBoundary *Policy            // Caps what Policies grant, nil when the role has none
+	BoundedPolicies     []Policy           // Policies constrained by PermissionsBoundary
+	Simulation          []SimulationResult // AWS policy simulator decisions, when requested
+	PolicyHistory       []PolicyVersion    // Versions of the policy asked for with history, newest first
+	Usage               []ServiceUsage     // IAM last-accessed data of IAMRole, when requested
+	Calls               []APICall          // Calls IAMRole made according to CloudTrail logs, with suggest
+	EscalationPaths     []EscalationPath   // Privilege-escalation paths the role's policies enable together
+	Score               int                // Risk score of what the role is effectively granted
+	Error               string             // Why a scan could not find the role or its policies
+}
+
+// SimulationResult is the AWS IAM policy simulator's decision for one action
+// on one resource
+type SimulationResult struct {
+	Action          string
+	Resource        string
+	Decision        string
+	MatchedPolicies []string // Policies holding the statements that decided it
+	MissingContext  []string // Condition keys the simulator had no value for
+}
+
+// PolicyVersion is a version of a managed policy
+type PolicyVersion struct {
+	VersionID   string // e.g. v3
+	CreateDate  time.Time
+	IsDefault   bool // The version in effect
+	Permissions []PermissionDisplay
 }
 
-type StatementInfo struct {
-	Effect    string
-	Actions   []string
-	Resources []string
+// ServiceUsage is when a role last used a service, according to IAM
+// last-accessed data
+type ServiceUsage struct {
+	Service  string        // Service namespace, e.g. s3
+	LastUsed time.Time     // Zero when not used within the tracking period
+	Actions  []ActionUsage // Actions IAM tracks individually, for the services it tracks them for
+}
+
+// ActionUsage is when a role last used an action
+type ActionUsage struct {
+	Action   string    // e.g. iam:CreateRole
+	LastUsed time.Time // Zero when not used within the tracking period
+}
+
+// APICall is an action a role performed on a resource, as recorded by
+// CloudTrail, and how often
+type APICall struct {
+	Action    string // e.g. s3:GetObject
+	Resource  string // ARN, or * when the event names no resource
+	Count     int
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// EscalationPath is a known way for a role to obtain permissions beyond its
+// own, and the permissions that let it
+type EscalationPath struct {
+	Path        string // The actions it needs, e.g. iam:PassRole + ec2:RunInstances
+	Description string
+	Grants      []Grant
+	Conditional bool // Depends on conditions not known to hold
+}
+
+// Grant is a permission of a policy
+type Grant struct {
+	Policy     string
+	Permission PermissionDisplay
 }
 
 func (p PermissionDisplay) String() string {
 	return fmt.Sprintf("%s %s on %s (Broad: %v, High Risk: %v, Has Condition: %v)",
-		p.Effect, p.Action, p.Resource, p.IsBroad, p.IsHighRisk, p.HasCondition)
+		p.Effect, p.ActionLabel(), p.ResourceLabel(), p.IsBroad, p.IsHighRisk, p.HasCondition)
+}
+
+// ActionLabel is the action as shown to users, marking NotAction exclusions
+func (p PermissionDisplay) ActionLabel() string {
+	if p.NotAction {
+		return "NOT " + p.Action
+	}
+	return p.Action
+}
+
+// IsConditional reports whether the permission only applies under
+// conditions that are not known to hold
+func (p PermissionDisplay) IsConditional() bool {
+	return p.HasCondition && p.ConditionResult != ConditionsHold
+}
+
+// IsInapplicable reports whether the permission's conditions are known not to
+// hold in the request context, so it neither grants nor denies anything
+func (p PermissionDisplay) IsInapplicable() bool {
+	return p.ConditionResult == ConditionsFail
+}
+
+// ResourceLabel is the resource as shown to users, marking NotResource
+// exclusions
+func (p PermissionDisplay) ResourceLabel() string {
+	if p.NotResource {
+		return "NOT " + p.Resource
+	}
+	return p.Resource
 }
 
 func (p Policy) String() string {
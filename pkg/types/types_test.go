@@ -33,6 +33,19 @@ func TestPermissionDisplay_String(t *testing.T) {
 			},
 			expected: "Allow s3:GetObject on arn:aws:s3:::my-bucket/* (Broad: false, High Risk: false, Has Condition: false)",
 		},
+		{
+			name: "not action and not resource",
+			perm: PermissionDisplay{
+				Action:      "iam:*",
+				Resource:    "arn:aws:s3:::payroll/*",
+				Effect:      "Allow",
+				IsBroad:     true,
+				IsHighRisk:  true,
+				NotAction:   true,
+				NotResource: true,
+			},
+			expected: "Allow NOT iam:* on NOT arn:aws:s3:::payroll/* (Broad: true, High Risk: true, Has Condition: false)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -171,3 +184,28 @@ func TestPodPermissions(t *testing.T) {
 	assert.Equal(t, "test-role", pod.IAMRole)
 	assert.Empty(t, pod.Policies)
 }
+
+func TestCondition_String(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition Condition
+		expected  string
+	}{
+		{
+			name:      "single value",
+			condition: Condition{Operator: "StringEquals", Key: "aws:SourceVpce", Values: []string{"vpce-1a2b3c4d"}},
+			expected:  "aws:SourceVpce StringEquals vpce-1a2b3c4d",
+		},
+		{
+			name:      "several values",
+			condition: Condition{Operator: "StringLike", Key: "s3:prefix", Values: []string{"home/", "home/${aws:username}/*"}},
+			expected:  "s3:prefix StringLike [home/, home/${aws:username}/*]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.condition.String())
+		})
+	}
+}